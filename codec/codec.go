@@ -2,11 +2,31 @@ package codec
 
 import (
 	"encoding"
+	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"golang.org/x/exp/constraints"
 )
 
+var (
+	registry sync.Map
+
+	lexMarshalerType   = reflect.TypeFor[lex.LexMarshaler]()
+	lexUnmarshalerType = reflect.TypeFor[lex.LexUnmarshaler]()
+)
+
+// Register registers the codec of the type T which takes precedence over the builtin codecs in CodecFor.
+// Codecs must be registered before the stores that use them are created.
+func Register[T any](c Codec[T]) {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+
+	registry.Store(reflect.TypeFor[T](), c)
+}
+
 // Codec is an interface for encoding and decoding values.
 type Codec[T any] interface {
 	Encoder[T]
@@ -23,30 +43,66 @@ type Decoder[T any] interface {
 	Decode(bz []byte) (T, error)
 }
 
-// CodecFor returns the codec for the given type.
+// CodecFor returns the codec for the given type or nil if there is none.
+// The codecs are looked up in the following order:
+//   - the codec registered by Register
+//   - the builtin codecs of string, int, int64, uint, uint64 and []byte
+//   - lex encoding for the types that implement lex.LexMarshaler and lex.LexUnmarshaler
+//   - encoding.BinaryMarshaler and encoding.BinaryUnmarshaler
+//   - encoding.TextMarshaler and encoding.TextUnmarshaler
+//   - lex encoding for the other integers, floats, bools, strings, byte slices and byte arrays like uuid.UUID
+//   - lex tuple encoding of the exported fields for structs
 func CodecFor[T any]() Codec[T] {
+	if c, ok := registry.Load(reflect.TypeFor[T]()); ok {
+		return c.(Codec[T])
+	}
+
 	rt := reflect.TypeFor[T]()
-	switch rt.Kind() {
-	case reflect.String:
+	switch rt {
+	case reflect.TypeFor[string]():
 		return any(stringCodec{}).(Codec[T])
-	case reflect.Int:
+	case reflect.TypeFor[int]():
 		return any(intCodec{}).(Codec[T])
-	case reflect.Int64:
+	case reflect.TypeFor[int64]():
 		return any(int64Codec{}).(Codec[T])
-	case reflect.Uint:
+	case reflect.TypeFor[uint]():
 		return any(uintCodec{}).(Codec[T])
-	case reflect.Uint64:
+	case reflect.TypeFor[uint64]():
 		return any(uint64Codec{}).(Codec[T])
-	case reflect.Slice:
-		if rt.Elem().Kind() == reflect.Uint8 {
-			return any(bytesCodec{}).(Codec[T])
-		}
+	case reflect.TypeFor[[]byte]():
+		return any(bytesCodec{}).(Codec[T])
 	}
 
+	ptr := reflect.PointerTo(rt)
+	if (rt.Implements(lexMarshalerType) || ptr.Implements(lexMarshalerType)) && ptr.Implements(lexUnmarshalerType) {
+		return lexCodec[T]{}
+	}
 	if rt.Implements(reflect.TypeFor[encoding.BinaryMarshaler]()) &&
-		reflect.TypeFor[*T]().Implements(reflect.TypeFor[encoding.BinaryUnmarshaler]()) {
+		ptr.Implements(reflect.TypeFor[encoding.BinaryUnmarshaler]()) {
 		return binaryCodec[T]{}
 	}
+	if rt.Implements(reflect.TypeFor[encoding.TextMarshaler]()) &&
+		ptr.Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return textCodec[T]{}
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return lexCodec[T]{}
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return lexCodec[T]{}
+		}
+	case reflect.Struct:
+		for i := 0; i < rt.NumField(); i++ {
+			if rt.Field(i).IsExported() {
+				return tupleCodec[T]{}
+			}
+		}
+	}
 
 	return nil
 }
@@ -136,3 +192,72 @@ func (binaryCodec[T]) Decode(bz []byte) (T, error) {
 	err := any(&v).(encoding.BinaryUnmarshaler).UnmarshalBinary(bz)
 	return v, err
 }
+
+type textCodec[T any] struct{}
+
+// Encode implements the Codec interface.
+func (textCodec[T]) Encode(v T) ([]byte, error) {
+	return any(v).(encoding.TextMarshaler).MarshalText()
+}
+
+// Decode implements the Codec interface.
+func (textCodec[T]) Decode(bz []byte) (T, error) {
+	var v T
+	err := any(&v).(encoding.TextUnmarshaler).UnmarshalText(bz)
+	return v, err
+}
+
+type lexCodec[T any] struct{}
+
+// Encode implements the Codec interface.
+func (lexCodec[T]) Encode(v T) ([]byte, error) {
+	return (&lex.Encoder{}).Encode(reflect.ValueOf(&v).Elem())
+}
+
+// Decode implements the Codec interface.
+func (lexCodec[T]) Decode(bz []byte) (T, error) {
+	var v T
+	err := (&lex.Decoder{}).Decode(bz, &v)
+	return v, err
+}
+
+// Varint returns a codec that encodes integers using the order-preserving variable-length encoding of
+// lex.EncodeVarint which takes fewer bytes for small values like sequential record ids.
+func Varint[T constraints.Integer]() Codec[T] {
+	return varintCodec[T]{}
+}
+
+type varintCodec[T constraints.Integer] struct{}
+
+// Encode implements the Codec interface.
+func (varintCodec[T]) Encode(v T) ([]byte, error) {
+	if v < 0 {
+		return lex.EncodeVarint(int64(v)), nil
+	}
+	return lex.EncodeUvarint(uint64(v)), nil
+}
+
+// Decode implements the Codec interface.
+func (varintCodec[T]) Decode(bz []byte) (T, error) {
+	var (
+		v  T
+		n  int
+		ok bool
+	)
+	if signed := ^T(0) < 0; signed {
+		var i int64
+		i, n = lex.DecodeVarint(bz)
+		v = T(i)
+		ok = int64(v) == i
+	} else {
+		var u uint64
+		u, n = lex.DecodeUvarint(bz)
+		v = T(u)
+		ok = uint64(v) == u
+	}
+	if n <= 0 || n != len(bz) || !ok {
+		return 0, fmt.Errorf("invalid varint of %T: %x", v, bz)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,164 @@
+package codec_test
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type level int8
+
+type point struct {
+	X, Y int32
+}
+
+type event struct {
+	At      time.Time
+	Id      uuid.UUID
+	Level   level
+	Name    string
+	Origin  point
+	Parent  *point
+	Payload []byte
+	hidden  int
+}
+
+type upper string
+
+func (u upper) Encode(v upper) ([]byte, error) { return bytes.ToUpper([]byte(v)), nil }
+
+func (u upper) Decode(bz []byte) (upper, error) { return upper(bz), nil }
+
+func roundTrip[T any](t *testing.T, values ...T) {
+	t.Helper()
+
+	c := codec.CodecFor[T]()
+	require.NotNil(t, c)
+
+	var encoded [][]byte
+	for _, v := range values {
+		bz, err := c.Encode(v)
+		require.NoError(t, err)
+		encoded = append(encoded, bz)
+
+		got, err := c.Decode(bz)
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+
+	// The values are given in ascending order.
+	for i := 1; i < len(encoded); i++ {
+		require.Negative(t, bytes.Compare(encoded[i-1], encoded[i]), "%v < %v", values[i-1], values[i])
+	}
+}
+
+func TestCodecFor(t *testing.T) {
+	t.Run("Builtin", func(t *testing.T) {
+		roundTrip(t, "a", "b")
+		roundTrip(t, -1, 0, 1)
+		roundTrip(t, int64(-1), int64(1))
+		roundTrip(t, uint(1), uint(2))
+		roundTrip(t, uint64(1), uint64(2))
+		roundTrip(t, []byte{0x01}, []byte{0x02})
+	})
+
+	t.Run("Numbers", func(t *testing.T) {
+		roundTrip(t, int8(-1), int8(1))
+		roundTrip(t, int16(-1), int16(1))
+		roundTrip(t, int32(-1), int32(1))
+		roundTrip(t, uint8(1), uint8(2))
+		roundTrip(t, uint16(1), uint16(2))
+		roundTrip(t, uint32(1), uint32(2))
+		roundTrip(t, float32(-1.5), float32(2))
+		roundTrip(t, -1.5, 2.0)
+		roundTrip(t, level(-1), level(1))
+	})
+
+	t.Run("Bool", func(t *testing.T) {
+		roundTrip(t, false, true)
+	})
+
+	t.Run("Byte array", func(t *testing.T) {
+		roundTrip(t, [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 1, 0})
+		roundTrip(t, uuid.UUID{0x01}, uuid.UUID{0x02})
+	})
+
+	t.Run("Lex marshaler", func(t *testing.T) {
+		roundTrip(t, lex.Decimal("-1.5"), lex.Decimal("2"))
+	})
+
+	t.Run("Text marshaler", func(t *testing.T) {
+		c := codec.CodecFor[net.IP]()
+		require.NotNil(t, c)
+
+		bz, err := c.Encode(net.IPv4(10, 0, 0, 1))
+		require.NoError(t, err)
+		require.Equal(t, []byte("10.0.0.1"), bz)
+
+		got, err := c.Decode(bz)
+		require.NoError(t, err)
+		require.True(t, net.IPv4(10, 0, 0, 1).Equal(got))
+	})
+
+	t.Run("Struct", func(t *testing.T) {
+		at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		roundTrip(t,
+			event{At: at, Id: uuid.UUID{0x01}, Level: -1, Name: "a", Parent: &point{X: 1}, Payload: []byte{}},
+			event{At: at, Id: uuid.UUID{0x01}, Level: 2, Name: "a", Origin: point{X: -5, Y: 3}, Payload: []byte{}},
+			event{At: at.Add(time.Second), Payload: []byte{0x01}},
+		)
+		roundTrip(t, point{X: -1, Y: 5}, point{X: 0, Y: -5}, point{X: 0, Y: 0})
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		require.Nil(t, codec.CodecFor[map[string]int]())
+		require.Nil(t, codec.CodecFor[[]int]())
+		require.Nil(t, codec.CodecFor[big.Float]())
+	})
+}
+
+func TestRegister(t *testing.T) {
+	codec.Register[upper](upper(""))
+
+	c := codec.CodecFor[upper]()
+	bz, err := c.Encode("foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("FOO"), bz)
+
+	require.Panics(t, func() { codec.Register[int](nil) })
+}
+
+func TestVarint(t *testing.T) {
+	c := codec.Varint[int64]()
+	values := []int64{math.MinInt64, -1000, -1, 0, 1, 1000, math.MaxInt64}
+	var prev []byte
+	for _, v := range values {
+		bz, err := c.Encode(v)
+		require.NoError(t, err)
+		require.Negative(t, bytes.Compare(prev, bz))
+		prev = bz
+
+		got, err := c.Decode(bz)
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+
+	bz, err := codec.Varint[uint32]().Encode(42)
+	require.NoError(t, err)
+	require.Len(t, bz, 2)
+
+	_, err = codec.Varint[uint8]().Decode(lex.EncodeVarint(256))
+	require.Error(t, err)
+	_, err = codec.Varint[uint64]().Decode(lex.EncodeVarint(-1))
+	require.Error(t, err)
+	_, err = codec.Varint[int]().Decode(append(lex.EncodeVarint(1), 0))
+	require.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package codec
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedMagic is the first byte of the values written by CompressedCodec.
+const compressedMagic = 0xe0
+
+// Compression is the algorithm used by CompressedCodec to compress values.
+type Compression byte
+
+const (
+	// NoCompression stores values as they are.
+	NoCompression Compression = iota
+	// Snappy compresses values using snappy which is fast and has a moderate ratio.
+	Snappy
+	// Zstd compresses values using zstandard which is slower but has a better ratio.
+	Zstd
+)
+
+// DefaultCompressionThreshold is the default size in bytes under which values are not compressed.
+const DefaultCompressionThreshold = 256
+
+// String implements the fmt.Stringer interface.
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Snappy:
+		return "snappy"
+	case Zstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("Compression(%d)", byte(c))
+	}
+}
+
+var (
+	zstdInit    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func zstdCoders() (*zstd.Encoder, *zstd.Decoder) {
+	zstdInit.Do(func() {
+		var err error
+		zstdEncoder, err = zstd.NewWriter(nil)
+		if err != nil {
+			panic(err)
+		}
+		zstdDecoder, err = zstd.NewReader(nil)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	return zstdEncoder, zstdDecoder
+}
+
+// CompressedCodec is a codec that compresses the output of another codec.
+// Every value is prefixed by a magic byte and the Compression it was written with, so the algorithm and
+// threshold can be changed at any time and all stored formats are decoded transparently.
+// Values that don't start with the header (e.g. written before compression was enabled) are passed to the
+// underlying codec as they are. Such values that begin with the magic byte followed by a Compression,
+// e.g. 0xe0 0x00, are taken for compressed ones, so they're not supported.
+type CompressedCodec[T any] struct {
+	codec     Codec[T]
+	alg       Compression
+	threshold int
+}
+
+// Compressed creates a new CompressedCodec that compresses values encoded by c using alg.
+func Compressed[T any](c Codec[T], alg Compression) *CompressedCodec[T] {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+	if alg > Zstd {
+		panic(fmt.Sprintf("unknown compression %s", alg))
+	}
+
+	return &CompressedCodec[T]{
+		codec:     c,
+		alg:       alg,
+		threshold: DefaultCompressionThreshold,
+	}
+}
+
+// WithThreshold sets the size in bytes under which values are stored uncompressed.
+func (c *CompressedCodec[T]) WithThreshold(n int) *CompressedCodec[T] {
+	if n < 0 {
+		panic("threshold must not be negative")
+	}
+
+	c.threshold = n
+	return c
+}
+
+// Encode implements the Codec interface.
+func (c *CompressedCodec[T]) Encode(v T) ([]byte, error) {
+	bz, err := c.codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.alg != NoCompression && len(bz) >= c.threshold {
+		compressed := compress(c.alg, bz)
+		// Incompressible values are stored as they are.
+		if len(compressed) < len(bz) {
+			return compressed, nil
+		}
+	}
+
+	return append([]byte{compressedMagic, byte(NoCompression)}, bz...), nil
+}
+
+func compress(alg Compression, bz []byte) []byte {
+	dst := []byte{compressedMagic, byte(alg)}
+	switch alg {
+	case Snappy:
+		return append(dst, s2.EncodeSnappy(nil, bz)...)
+	case Zstd:
+		enc, _ := zstdCoders()
+		return enc.EncodeAll(bz, dst)
+	default:
+		panic(fmt.Sprintf("unknown compression %s", alg))
+	}
+}
+
+// Decode implements the Codec interface.
+func (c *CompressedCodec[T]) Decode(bz []byte) (T, error) {
+	bz, err := decompress(bz)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return c.codec.Decode(bz)
+}
+
+// decompress strips the header of a value written by CompressedCodec and decompresses it if needed.
+func decompress(bz []byte) ([]byte, error) {
+	if len(bz) < 2 || bz[0] != compressedMagic {
+		return bz, nil
+	}
+
+	switch Compression(bz[1]) {
+	case NoCompression:
+		return bz[2:], nil
+	case Snappy:
+		out, err := s2.Decode(nil, bz[2:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress snappy value: %w", err)
+		}
+		return out, nil
+	case Zstd:
+		_, dec := zstdCoders()
+		out, err := dec.DecodeAll(bz[2:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress zstd value: %w", err)
+		}
+		return out, nil
+	default:
+		return bz, nil
+	}
+}
+
+// IsStale implements the Upgradable interface by delegating to the underlying codec.
+func (c *CompressedCodec[T]) IsStale(bz []byte) (bool, error) {
+	if _, ok := c.codec.(Upgradable); !ok {
+		return false, nil
+	}
+
+	bz, err := decompress(bz)
+	if err != nil {
+		return false, err
+	}
+
+	return isStale(c.codec, bz)
+}
+
+// WriteBack implements the Upgradable interface by delegating to the underlying codec.
+func (c *CompressedCodec[T]) WriteBack() bool {
+	return writeBack(c.codec)
+}
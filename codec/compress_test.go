@@ -0,0 +1,99 @@
+package codec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedCodec(t *testing.T) {
+	var (
+		small = &document{Name: "foo"}
+		large = &document{Name: strings.Repeat("foo", 1000), Tags: []string{"a", "b"}}
+	)
+
+	tests := []struct {
+		alg    codec.Compression
+		header []byte
+	}{
+		{codec.NoCompression, []byte{0xe0, 0}},
+		{codec.Snappy, []byte{0xe0, 1}},
+		{codec.Zstd, []byte{0xe0, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.alg.String(), func(t *testing.T) {
+			c := codec.Compressed(codec.JSON[*document](), test.alg)
+
+			bz, err := c.Encode(small)
+			require.NoError(t, err)
+			require.Equal(t, []byte{0xe0, 0}, bz[:2])
+			got, err := c.Decode(bz)
+			require.NoError(t, err)
+			require.Equal(t, small, got)
+
+			bz, err = c.Encode(large)
+			require.NoError(t, err)
+			require.Equal(t, test.header, bz[:2])
+			if test.alg != codec.NoCompression {
+				require.Less(t, len(bz), len(large.Name))
+			}
+			got, err = c.Decode(bz)
+			require.NoError(t, err)
+			require.Equal(t, large, got)
+
+			// Values written with another algorithm are decoded as well.
+			for _, other := range tests {
+				bz, err := codec.Compressed(codec.JSON[*document](), other.alg).Encode(large)
+				require.NoError(t, err)
+				got, err := c.Decode(bz)
+				require.NoError(t, err)
+				require.Equal(t, large, got)
+			}
+		})
+	}
+
+	t.Run("Threshold", func(t *testing.T) {
+		c := codec.Compressed(codec.JSON[*document](), codec.Snappy).WithThreshold(0)
+
+		bz, err := c.Encode(&document{Name: strings.Repeat("a", 64)})
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xe0, 1}, bz[:2])
+	})
+
+	t.Run("Incompressible", func(t *testing.T) {
+		c := codec.Compressed(codec.CodecFor[[]byte](), codec.Zstd).WithThreshold(0)
+
+		bz, err := c.Encode([]byte{1, 2, 3})
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xe0, 0, 1, 2, 3}, bz)
+	})
+
+	t.Run("Uncompressed", func(t *testing.T) {
+		c := codec.Compressed(codec.JSON[*document](), codec.Zstd)
+
+		got, err := c.Decode([]byte(`{"Name":"foo"}`))
+		require.NoError(t, err)
+		require.Equal(t, small, got)
+
+		// Values written before compression was enabled are passed as they are unless they begin with a header.
+		raw := codec.Compressed(codec.CodecFor[[]byte](), codec.Zstd)
+		for _, legacy := range [][]byte{{0}, {1, 2, 3}, {0xe0}, {0xe0, 0xff}} {
+			got, err := raw.Decode(legacy)
+			require.NoError(t, err)
+			require.Equal(t, legacy, got)
+		}
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		c := codec.Compressed(codec.JSON[*document](), codec.Zstd)
+
+		_, err := c.Decode(append([]byte{0xe0, 2}, bytes.Repeat([]byte{0xff}, 8)...))
+		require.Error(t, err)
+	})
+
+	require.Panics(t, func() { codec.Compressed(codec.JSON[*document](), 10) })
+}
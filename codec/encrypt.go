@@ -0,0 +1,243 @@
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// encryptedMagic is the first byte of the values written by EncryptedCodec.
+const encryptedMagic = 0xe1
+
+// encryptedHeaderSize is the size of the magic byte and the key id preceding the nonce.
+const encryptedHeaderSize = 1 + 4
+
+var (
+	// ErrNotEncrypted is returned by EncryptedCodec when decoding a value that's not encrypted.
+	ErrNotEncrypted = errors.New("value is not encrypted")
+	// ErrKeyNotFound is returned by key providers when there's no key with the given id.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// KeyProvider provides the keys used by EncryptedCodec.
+// Keys must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256 and must never change for an id.
+type KeyProvider interface {
+	// CurrentKey returns the id and the key that new values are encrypted with.
+	CurrentKey() (id uint32, key []byte, err error)
+	// Key returns the key with the given id.
+	Key(id uint32) ([]byte, error)
+}
+
+// KeyRing is an in-memory KeyProvider.
+type KeyRing struct {
+	mu      sync.RWMutex
+	keys    map[uint32][]byte
+	current uint32
+	hasKey  bool
+}
+
+// NewKeyRing creates a new empty KeyRing.
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		keys: map[uint32][]byte{},
+	}
+}
+
+// Add adds the key with the given id and makes it the current key.
+func (r *KeyRing) Add(id uint32, key []byte) error {
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("invalid key %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[id]; ok {
+		return fmt.Errorf("key %d already exists", id)
+	}
+	r.keys[id] = append([]byte(nil), key...)
+	r.current = id
+	r.hasKey = true
+	return nil
+}
+
+// CurrentKey implements the KeyProvider interface.
+func (r *KeyRing) CurrentKey() (uint32, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.hasKey {
+		return 0, nil, ErrKeyNotFound
+	}
+	return r.current, r.keys[r.current], nil
+}
+
+// Key implements the KeyProvider interface.
+func (r *KeyRing) Key(id uint32) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrKeyNotFound, id)
+	}
+	return key, nil
+}
+
+// EncryptedCodec is a codec that encrypts the output of another codec using AES-GCM.
+// Every value is written as a magic byte, the big-endian id of the key it was encrypted with,
+// the nonce and the sealed data, so values are decrypted by whichever key they were written with.
+// It should be the outermost codec, e.g. compression must happen before encryption.
+type EncryptedCodec[T any] struct {
+	codec          Codec[T]
+	keys           KeyProvider
+	allowPlaintext bool
+	aeads          sync.Map
+}
+
+// Encrypted creates a new EncryptedCodec that encrypts values encoded by c with the keys of kp.
+func Encrypted[T any](c Codec[T], kp KeyProvider) *EncryptedCodec[T] {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+	if kp == nil {
+		panic("key provider must not be nil")
+	}
+
+	return &EncryptedCodec[T]{
+		codec: c,
+		keys:  kp,
+	}
+}
+
+// AllowPlaintext makes the codec decode the values that are not encrypted using the underlying codec
+// which is useful to migrate existing data. Plaintext values starting with the magic byte 0xe1 are not supported.
+func (c *EncryptedCodec[T]) AllowPlaintext() *EncryptedCodec[T] {
+	c.allowPlaintext = true
+	return c
+}
+
+// Encode implements the Codec interface.
+func (c *EncryptedCodec[T]) Encode(v T) ([]byte, error) {
+	bz, err := c.codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	id, key, err := c.keys.CurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current key: %w", err)
+	}
+	aead, err := c.aead(id, key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, encryptedHeaderSize+aead.NonceSize(), encryptedHeaderSize+aead.NonceSize()+len(bz)+aead.Overhead())
+	out[0] = encryptedMagic
+	binary.BigEndian.PutUint32(out[1:], id)
+	nonce := out[encryptedHeaderSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(out, nonce, bz, out[:encryptedHeaderSize]), nil
+}
+
+// Decode implements the Codec interface.
+func (c *EncryptedCodec[T]) Decode(bz []byte) (T, error) {
+	data, err := c.decrypt(bz)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return c.codec.Decode(data)
+}
+
+// decrypt returns the plaintext of a value written by Encode.
+func (c *EncryptedCodec[T]) decrypt(bz []byte) ([]byte, error) {
+	id, ok := EncryptionKeyId(bz)
+	if !ok {
+		if c.allowPlaintext {
+			return bz, nil
+		}
+		return nil, ErrNotEncrypted
+	}
+
+	aead, err := c.aead(id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(bz) < encryptedHeaderSize+aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted value is too short")
+	}
+
+	nonce := bz[encryptedHeaderSize : encryptedHeaderSize+aead.NonceSize()]
+	data, err := aead.Open(nil, nonce, bz[encryptedHeaderSize+aead.NonceSize():], bz[:encryptedHeaderSize])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value with key %d: %w", id, err)
+	}
+
+	return data, nil
+}
+
+// aead returns the cached cipher of the key id and creates it from key or the key provider if it's not cached.
+func (c *EncryptedCodec[T]) aead(id uint32, key []byte) (cipher.AEAD, error) {
+	if aead, ok := c.aeads.Load(id); ok {
+		return aead.(cipher.AEAD), nil
+	}
+
+	if key == nil {
+		var err error
+		key, err = c.keys.Key(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get key %d: %w", id, err)
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher of key %d: %w", id, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher of key %d: %w", id, err)
+	}
+
+	c.aeads.Store(id, aead)
+	return aead, nil
+}
+
+// IsStale implements the Upgradable interface by delegating to the underlying codec.
+func (c *EncryptedCodec[T]) IsStale(bz []byte) (bool, error) {
+	if _, ok := c.codec.(Upgradable); !ok {
+		return false, nil
+	}
+
+	bz, err := c.decrypt(bz)
+	if err != nil {
+		return false, err
+	}
+
+	return isStale(c.codec, bz)
+}
+
+// WriteBack implements the Upgradable interface by delegating to the underlying codec.
+func (c *EncryptedCodec[T]) WriteBack() bool {
+	return writeBack(c.codec)
+}
+
+// EncryptionKeyId returns the id of the key that the value written by EncryptedCodec is encrypted with.
+// It returns false if the value is not encrypted.
+func EncryptionKeyId(bz []byte) (uint32, bool) {
+	if len(bz) < encryptedHeaderSize || bz[0] != encryptedMagic {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint32(bz[1:encryptedHeaderSize]), true
+}
@@ -0,0 +1,74 @@
+package codec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedCodec(t *testing.T) {
+	keys := codec.NewKeyRing()
+	require.NoError(t, keys.Add(1, bytes.Repeat([]byte{1}, 32)))
+	require.Error(t, keys.Add(1, bytes.Repeat([]byte{1}, 32)))
+	require.Error(t, keys.Add(2, []byte("short")))
+
+	c := codec.Encrypted(codec.JSON[*document](), keys)
+	value := &document{Name: "secret"}
+
+	old, err := c.Encode(value)
+	require.NoError(t, err)
+	require.NotContains(t, string(old), "secret")
+	id, ok := codec.EncryptionKeyId(old)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), id)
+
+	// Values of the same input must not be equal because of the random nonce.
+	again, err := c.Encode(value)
+	require.NoError(t, err)
+	require.NotEqual(t, old, again)
+
+	require.NoError(t, keys.Add(2, bytes.Repeat([]byte{2}, 16)))
+	bz, err := c.Encode(value)
+	require.NoError(t, err)
+	id, _ = codec.EncryptionKeyId(bz)
+	require.Equal(t, uint32(2), id)
+
+	for _, bz := range [][]byte{old, bz} {
+		got, err := c.Decode(bz)
+		require.NoError(t, err)
+		require.Equal(t, value, got)
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := bytes.Clone(bz)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err := c.Decode(tampered)
+		require.Error(t, err)
+
+		// The key id is authenticated as well.
+		tampered = bytes.Clone(old)
+		tampered[4] = 2
+		_, err = c.Decode(tampered)
+		require.Error(t, err)
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		_, err := codec.Encrypted(codec.JSON[*document](), codec.NewKeyRing()).Decode(bz)
+		require.ErrorIs(t, err, codec.ErrKeyNotFound)
+
+		_, err = codec.Encrypted(codec.JSON[*document](), codec.NewKeyRing()).Encode(value)
+		require.ErrorIs(t, err, codec.ErrKeyNotFound)
+	})
+
+	t.Run("Plaintext", func(t *testing.T) {
+		plain := []byte(`{"Name":"secret"}`)
+		_, err := c.Decode(plain)
+		require.ErrorIs(t, err, codec.ErrNotEncrypted)
+
+		got, err := codec.Encrypted(codec.JSON[*document](), keys).AllowPlaintext().Decode(plain)
+		require.NoError(t, err)
+		require.Equal(t, value, got)
+	})
+}
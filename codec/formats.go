@@ -0,0 +1,99 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	msgpack "github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// JSON returns a codec that serializes values using encoding/json.
+func JSON[T any]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+type jsonCodec[T any] struct{}
+
+// Encode implements the Codec interface.
+func (jsonCodec[T]) Encode(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode implements the Codec interface.
+func (jsonCodec[T]) Decode(bz []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(bz, &v)
+	return v, err
+}
+
+// Msgpack returns a codec that serializes values using msgpack.
+func Msgpack[T any]() Codec[T] {
+	return msgpackCodec[T]{}
+}
+
+type msgpackCodec[T any] struct{}
+
+// Encode implements the Codec interface.
+func (msgpackCodec[T]) Encode(v T) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Decode implements the Codec interface.
+func (msgpackCodec[T]) Decode(bz []byte) (T, error) {
+	var v T
+	err := msgpack.Unmarshal(bz, &v)
+	return v, err
+}
+
+// Gob returns a codec that serializes values using encoding/gob.
+// Each value is encoded with its own type information so that it can be decoded independently.
+func Gob[T any]() Codec[T] {
+	return gobCodec[T]{}
+}
+
+type gobCodec[T any] struct{}
+
+// Encode implements the Codec interface.
+func (gobCodec[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements the Codec interface.
+func (gobCodec[T]) Decode(bz []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(bz)).Decode(&v)
+	return v, err
+}
+
+// Proto returns a codec that serializes protobuf messages using the wire format.
+// T must be a pointer to a generated message type such as *types.PetRecord.
+func Proto[T proto.Message]() Codec[T] {
+	if reflect.TypeFor[T]().Kind() != reflect.Pointer {
+		panic(fmt.Sprintf("proto message %s must be a pointer", reflect.TypeFor[T]()))
+	}
+
+	return protoCodec[T]{}
+}
+
+type protoCodec[T proto.Message] struct{}
+
+// Encode implements the Codec interface.
+func (protoCodec[T]) Encode(v T) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+// Decode implements the Codec interface.
+func (protoCodec[T]) Decode(bz []byte) (T, error) {
+	var zero T
+	v := zero.ProtoReflect().New().Interface().(T)
+	err := proto.Unmarshal(bz, v)
+	return v, err
+}
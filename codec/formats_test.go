@@ -0,0 +1,57 @@
+package codec_test
+
+import (
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type document struct {
+	Name string
+	Tags []string
+	Size int64
+}
+
+func TestFormats(t *testing.T) {
+	value := &document{Name: "foo", Tags: []string{"a", "b"}, Size: 42}
+
+	tests := []struct {
+		name  string
+		codec codec.Codec[*document]
+	}{
+		{"JSON", codec.JSON[*document]()},
+		{"Msgpack", codec.Msgpack[*document]()},
+		{"Gob", codec.Gob[*document]()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bz, err := test.codec.Encode(value)
+			require.NoError(t, err)
+
+			got, err := test.codec.Decode(bz)
+			require.NoError(t, err)
+			require.Equal(t, value, got)
+
+			_, err = test.codec.Decode([]byte{0xff, 0x00})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestProto(t *testing.T) {
+	c := codec.Proto[*wrapperspb.StringValue]()
+
+	bz, err := c.Encode(wrapperspb.String("foo"))
+	require.NoError(t, err)
+
+	got, err := c.Decode(bz)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(wrapperspb.String("foo"), got))
+
+	_, err = c.Decode([]byte{0xff})
+	require.Error(t, err)
+}
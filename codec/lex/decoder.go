@@ -0,0 +1,139 @@
+package lex
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Decoder decodes values that are encoded by Encoder including the types that implement LexUnmarshaler.
+type Decoder struct{}
+
+// Decode decodes the given bytes into the value that v points to. v can also be a settable reflect.Value.
+// Nil pointers are encoded as empty bytes so empty bytes are decoded as nil pointers.
+func (d *Decoder) Decode(bz []byte, v any) error {
+	rv, ok := v.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(v)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return fmt.Errorf("decode target must be a non-nil pointer, got %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if !rv.CanSet() {
+		return fmt.Errorf("decode target of type %s is not settable", rv.Type())
+	}
+
+	return d.decodeRV(bz, rv)
+}
+
+func (d *Decoder) decodeRV(bz []byte, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if len(bz) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decodeRV(bz, v.Elem())
+	}
+
+	if v.Addr().Type().Implements(lexUnmarshalerType) {
+		return v.Addr().Interface().(LexUnmarshaler).UnmarshalLex(bz)
+	}
+
+	if n := EncodedSizeOf(v.Type()); n > 0 && len(bz) != n {
+		return fmt.Errorf("invalid length %d for %s, expected %d", len(bz), v.Type(), n)
+	}
+
+	switch v.Type() {
+	case timeType:
+		v.Set(reflect.ValueOf(DecodeTime(bz)))
+		return nil
+	case bigIntType:
+		bi, err := DecodeBigInt(bz)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(bi).Elem())
+		return nil
+	case decimalType:
+		d, err := DecodeDecimal(bz)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(bz[0] != 0x00)
+	case reflect.Int8:
+		v.SetInt(int64(DecodeInt8(bz)))
+	case reflect.Uint8:
+		v.SetUint(uint64(bz[0]))
+	case reflect.Int16:
+		v.SetInt(int64(DecodeInt16(bz)))
+	case reflect.Uint16:
+		v.SetUint(uint64(DecodeUint16(bz)))
+	case reflect.Int32:
+		v.SetInt(int64(DecodeInt32(bz)))
+	case reflect.Uint32:
+		v.SetUint(uint64(DecodeUint32(bz)))
+	case reflect.Int, reflect.Int64:
+		v.SetInt(DecodeInt64(bz))
+	case reflect.Uint, reflect.Uint64:
+		v.SetUint(DecodeUint64(bz))
+	case reflect.Float32:
+		v.SetFloat(float64(DecodeFloat32(bz)))
+	case reflect.Float64:
+		v.SetFloat(DecodeFloat64(bz))
+	case reflect.String:
+		v.SetString(string(bz))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.SetBytes(append([]byte{}, bz...))
+	case reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		reflect.Copy(v, reflect.ValueOf(bz))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// EncodedSizeOf returns the size of the encoded values of the given type or 0 if they are variable-sized.
+func EncodedSizeOf(t reflect.Type) int {
+	switch {
+	case t.Implements(lexMarshalerType) || reflect.PointerTo(t).Implements(lexMarshalerType):
+		return 0
+	case t == timeType:
+		return 8
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		return t.Len()
+	default:
+		return EncodedSize(t.Kind())
+	}
+}
+
+// EncodedSize returns the size of the encoded values of the given kind or 0 if they are variable-sized.
+func EncodedSize(k reflect.Kind) int {
+	switch k {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16, reflect.Uint16:
+		return 2
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Float64:
+		return 8
+	default:
+		return 0
+	}
+}
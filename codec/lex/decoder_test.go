@@ -0,0 +1,60 @@
+package lex_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_Decode(t *testing.T) {
+	five := 5
+	tests := []any{
+		true,
+		int8(-3),
+		uint8(200),
+		int16(math.MinInt16),
+		uint16(math.MaxUint16),
+		int32(-70000),
+		uint32(70000),
+		-5,
+		int64(math.MaxInt64),
+		uint(7),
+		uint64(math.MaxUint64),
+		float32(-1.5),
+		2.25,
+		"foo",
+		[]byte{0x00, 0x01},
+		&five,
+		(*int)(nil),
+	}
+
+	enc, dec := &lex.Encoder{}, &lex.Decoder{}
+	for _, v := range tests {
+		t.Run(reflect.TypeOf(v).String(), func(t *testing.T) {
+			bz, err := enc.Encode(v)
+			require.NoError(t, err)
+
+			got := reflect.New(reflect.TypeOf(v))
+			require.NoError(t, dec.Decode(bz, got.Interface()))
+			require.Equal(t, v, got.Elem().Interface())
+		})
+	}
+}
+
+func TestDecoder_Decode_Invalid(t *testing.T) {
+	dec := &lex.Decoder{}
+
+	var i int64
+	require.Error(t, dec.Decode([]byte{0x01}, &i))
+	require.Error(t, dec.Decode(lex.EncodeInt64(1), i))
+	require.Error(t, dec.Decode(lex.EncodeInt64(1), reflect.ValueOf(i)))
+
+	var s struct{}
+	require.Error(t, dec.Decode([]byte{}, &s))
+
+	require.NoError(t, dec.Decode(lex.EncodeInt64(1), reflect.ValueOf(&i).Elem()))
+	require.Equal(t, int64(1), i)
+}
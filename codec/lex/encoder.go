@@ -2,10 +2,14 @@ package lex
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
+	"time"
 )
 
 // Encoder encodes values lexicographically.
+// Besides the basic kinds, it supports time.Time, fixed-size byte arrays like uuid.UUID, big.Int, Decimal and the
+// types that implement LexMarshaler.
 type Encoder struct{}
 
 // MustEncode encodes the given value and panics if there is an error.
@@ -28,6 +32,29 @@ func (e *Encoder) Encode(v any) ([]byte, error) {
 }
 
 func (e *Encoder) encodeRV(v reflect.Value) ([]byte, error) {
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return []byte{}, nil
+	}
+
+	if v.Type().Implements(lexMarshalerType) {
+		return v.Interface().(LexMarshaler).MarshalLex()
+	}
+	if reflect.PointerTo(v.Type()).Implements(lexMarshalerType) {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface().(LexMarshaler).MarshalLex()
+	}
+
+	switch v.Type() {
+	case timeType:
+		return EncodeTime(v.Interface().(time.Time)), nil
+	case bigIntType:
+		bi := v.Interface().(big.Int)
+		return EncodeBigInt(&bi), nil
+	case decimalType:
+		return EncodeDecimal(v.Interface().(Decimal))
+	}
+
 	switch v.Kind() {
 	case reflect.Bool:
 		if v.Bool() {
@@ -58,12 +85,15 @@ func (e *Encoder) encodeRV(v reflect.Value) ([]byte, error) {
 		return []byte(v.String()), nil
 	case reflect.Array, reflect.Slice:
 		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Array {
+				// Bytes of arrays are only accessible if they're addressable.
+				bz := make([]byte, v.Len())
+				reflect.Copy(reflect.ValueOf(bz), v)
+				return bz, nil
+			}
 			return v.Bytes(), nil
 		}
 	case reflect.Pointer:
-		if v.IsNil() {
-			return []byte{}, nil
-		}
 		return e.encodeRV(v.Elem())
 	}
 
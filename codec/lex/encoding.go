@@ -20,11 +20,22 @@ func EncodeInt16(v int16) []byte {
 	return EncodeUint16(uint16(v) ^ (1 << 15))
 }
 
+// DecodeInt16 returns the int16 representation of the given byte slice which is decoded from lexicographical order.
+func DecodeInt16(b []byte) int16 {
+	u := DecodeUint16(b)
+	return int16(u ^ (1 << 15))
+}
+
 // EncodeUint16 returns the byte slice representation of the given uint16.
 func EncodeUint16(v uint16) []byte {
 	return binary.BigEndian.AppendUint16(nil, v)
 }
 
+// DecodeUint16 returns the uint16 representation of the given byte slice.
+func DecodeUint16(b []byte) uint16 {
+	return binary.BigEndian.Uint16(b)
+}
+
 // EncodeInt32 returns the byte slice representation of the given int32 which is encoded in lexicographical order.
 func EncodeInt32(v int32) []byte {
 	return EncodeUint32(uint32(v) ^ (1 << 31))
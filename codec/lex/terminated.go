@@ -0,0 +1,65 @@
+package lex
+
+import "fmt"
+
+// EncodeTerminated returns the self-delimiting representation of the given byte slice which is encoded in
+// lexicographical order. Zero bytes are escaped as 0x00 0xff and the value is terminated by 0x00 0x01,
+// so no encoded value is a prefix of another and the values can be concatenated without padding.
+func EncodeTerminated(b []byte) []byte {
+	return appendTerminated(nil, b, 0x00)
+}
+
+// EncodeTerminatedDesc is like EncodeTerminated but the values are encoded in descending order.
+// Unlike inverting the variable-length values, e.g. "ab" is correctly sorted after "abc".
+func EncodeTerminatedDesc(b []byte) []byte {
+	return appendTerminated(nil, b, 0xff)
+}
+
+func appendTerminated(dst, b []byte, mask byte) []byte {
+	for _, c := range b {
+		if c == 0x00 {
+			dst = append(dst, mask, 0xff^mask)
+		} else {
+			dst = append(dst, c^mask)
+		}
+	}
+	return append(dst, mask, 0x01^mask)
+}
+
+// DecodeTerminated decodes the value encoded by EncodeTerminated at the beginning of b
+// and returns it with the number of bytes read.
+func DecodeTerminated(b []byte) ([]byte, int, error) {
+	return decodeTerminated(b, 0x00)
+}
+
+// DecodeTerminatedDesc decodes the value encoded by EncodeTerminatedDesc at the beginning of b
+// and returns it with the number of bytes read.
+func DecodeTerminatedDesc(b []byte) ([]byte, int, error) {
+	return decodeTerminated(b, 0xff)
+}
+
+func decodeTerminated(b []byte, mask byte) ([]byte, int, error) {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		c := b[i] ^ mask
+		if c != 0x00 {
+			out = append(out, c)
+			continue
+		}
+
+		if i+1 >= len(b) {
+			break
+		}
+		switch b[i+1] ^ mask {
+		case 0x01:
+			return out, i + 2, nil
+		case 0xff:
+			out = append(out, 0x00)
+			i++
+		default:
+			return nil, 0, fmt.Errorf("invalid escape sequence at %d", i)
+		}
+	}
+
+	return nil, 0, fmt.Errorf("missing terminator")
+}
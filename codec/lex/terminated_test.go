@@ -0,0 +1,40 @@
+package lex_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeTerminated(t *testing.T) {
+	values := [][]byte{{}, {0x00}, {0x00, 0x00}, {0x00, 0x01}, []byte("a"), []byte("ab"), []byte("ab\x00"), []byte("abc"), {0xff}}
+
+	for i, v := range values {
+		asc := lex.EncodeTerminated(v)
+		desc := lex.EncodeTerminatedDesc(v)
+		if i > 0 {
+			require.Negative(t, bytes.Compare(lex.EncodeTerminated(values[i-1]), asc), "%q < %q", values[i-1], v)
+			require.Positive(t, bytes.Compare(lex.EncodeTerminatedDesc(values[i-1]), desc), "%q > %q", values[i-1], v)
+		}
+
+		// Decoding stops at the terminator.
+		got, n, err := lex.DecodeTerminated(append(asc, 0x00, 0x01))
+		require.NoError(t, err)
+		require.Equal(t, len(asc), n)
+		require.Equal(t, v, got)
+
+		got, n, err = lex.DecodeTerminatedDesc(append(desc, 0xff, 0xfe))
+		require.NoError(t, err)
+		require.Equal(t, len(desc), n)
+		require.Equal(t, v, got)
+	}
+
+	require.Equal(t, []byte{'a', 0x00, 0xff, 0x00, 0x01}, lex.EncodeTerminated([]byte{'a', 0x00}))
+
+	for _, invalid := range [][]byte{nil, []byte("ab"), {'a', 0x00}, {'a', 0x00, 0x02}} {
+		_, _, err := lex.DecodeTerminated(invalid)
+		require.Error(t, err, "%x", invalid)
+	}
+}
@@ -0,0 +1,373 @@
+package lex
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type codes of the tuple elements. The order of the codes determines the order of the elements of different types.
+const (
+	tupleNil     byte = 0x00
+	tupleBytes   byte = 0x01
+	tupleString  byte = 0x02
+	tupleNested  byte = 0x05
+	tupleIntZero byte = 0x14 // 0x0c-0x13 are negative and 0x15-0x1c are positive integers of 8-1 and 1-8 bytes.
+	tupleFloat32 byte = 0x20
+	tupleFloat64 byte = 0x21
+	tupleFalse   byte = 0x26
+	tupleTrue    byte = 0x27
+	tupleUUID    byte = 0x30
+	tupleTime    byte = 0x33
+
+	tupleEscape byte = 0xff
+)
+
+// Tuple is a sequence of heterogeneous values that is encoded in a self-delimiting and order-preserving way,
+// i.e. the encoded tuples sort the same as the tuples compared element by element, and a tuple sorts before
+// all of the tuples that it is a prefix of. Elements of different types are ordered by their types.
+//
+// Supported elements are nil, bool, signed and unsigned integers, floats, strings, byte slices, nested tuples,
+// uuid.UUID and time.Time which is stored as UTC nanoseconds since epoch. Other types that implement
+// encoding.BinaryMarshaler are stored as byte slices which doesn't preserve their order.
+// Integers of all sizes are decoded as int64, or uint64 if they don't fit in int64.
+type Tuple []any
+
+// EncodeTuple encodes the given values as a tuple.
+func EncodeTuple(vs ...any) ([]byte, error) {
+	return Tuple(vs).Encode()
+}
+
+// Encode encodes the tuple.
+func (t Tuple) Encode() ([]byte, error) {
+	return t.append(nil, false)
+}
+
+func (t Tuple) append(bz []byte, nested bool) ([]byte, error) {
+	for i, v := range t {
+		var err error
+		bz, err = appendElement(bz, v, nested)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode element %d: %w", i, err)
+		}
+	}
+	return bz, nil
+}
+
+func appendElement(bz []byte, v any, nested bool) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		if nested {
+			// Nil is escaped in nested tuples to be distinguishable from the terminator.
+			return append(bz, tupleNil, tupleEscape), nil
+		}
+		return append(bz, tupleNil), nil
+	case bool:
+		if v {
+			return append(bz, tupleTrue), nil
+		}
+		return append(bz, tupleFalse), nil
+	case int:
+		return appendInt(bz, int64(v)), nil
+	case int8:
+		return appendInt(bz, int64(v)), nil
+	case int16:
+		return appendInt(bz, int64(v)), nil
+	case int32:
+		return appendInt(bz, int64(v)), nil
+	case int64:
+		return appendInt(bz, v), nil
+	case uint:
+		return appendUint(bz, uint64(v)), nil
+	case uint8:
+		return appendUint(bz, uint64(v)), nil
+	case uint16:
+		return appendUint(bz, uint64(v)), nil
+	case uint32:
+		return appendUint(bz, uint64(v)), nil
+	case uint64:
+		return appendUint(bz, v), nil
+	case float32:
+		return append(append(bz, tupleFloat32), EncodeFloat32(v)...), nil
+	case float64:
+		return append(append(bz, tupleFloat64), EncodeFloat64(v)...), nil
+	case string:
+		return appendEscaped(append(bz, tupleString), []byte(v)), nil
+	case []byte:
+		return appendEscaped(append(bz, tupleBytes), v), nil
+	case []any:
+		return appendElement(bz, Tuple(v), nested)
+	case Tuple:
+		bz, err := v.append(append(bz, tupleNested), true)
+		if err != nil {
+			return nil, err
+		}
+		return append(bz, tupleNil), nil
+	case uuid.UUID:
+		return append(append(bz, tupleUUID), v[:]...), nil
+	case time.Time:
+		return append(append(bz, tupleTime), EncodeInt64(v.UnixNano())...), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %T: %w", v, err)
+		}
+		return appendEscaped(append(bz, tupleBytes), b), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+func appendInt(bz []byte, v int64) []byte {
+	if v >= 0 {
+		return appendUint(bz, uint64(v))
+	}
+
+	u := uint64(-v)
+	n := minBytes(u)
+	bz = append(bz, tupleIntZero-byte(n))
+	// One's complement makes the larger magnitudes sort first.
+	return append(bz, Invert(binary.BigEndian.AppendUint64(nil, u)[8-n:])...)
+}
+
+func appendUint(bz []byte, v uint64) []byte {
+	n := minBytes(v)
+	bz = append(bz, tupleIntZero+byte(n))
+	return append(bz, binary.BigEndian.AppendUint64(nil, v)[8-n:]...)
+}
+
+func minBytes(v uint64) int {
+	n := 0
+	for ; v > 0; v >>= 8 {
+		n++
+	}
+	return n
+}
+
+// appendEscaped appends b escaping the 0x00 bytes as 0x00 0xff and terminates it with 0x00.
+func appendEscaped(bz []byte, b []byte) []byte {
+	for _, c := range b {
+		bz = append(bz, c)
+		if c == 0x00 {
+			bz = append(bz, tupleEscape)
+		}
+	}
+	return append(bz, 0x00)
+}
+
+// DecodeTuple decodes a tuple that is encoded by Tuple.Encode.
+func DecodeTuple(bz []byte) (Tuple, error) {
+	t, rest, err := decodeTuple(bz, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("unexpected %d trailing bytes", len(rest))
+	}
+	return t, nil
+}
+
+func decodeTuple(bz []byte, nested bool) (Tuple, []byte, error) {
+	t := Tuple{}
+	for len(bz) > 0 {
+		if nested && bz[0] == tupleNil {
+			if len(bz) > 1 && bz[1] == tupleEscape {
+				t = append(t, nil)
+				bz = bz[2:]
+				continue
+			}
+			return t, bz[1:], nil
+		}
+
+		v, rest, err := decodeElement(bz)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode element %d: %w", len(t), err)
+		}
+		t = append(t, v)
+		bz = rest
+	}
+	if nested {
+		return nil, nil, errors.New("unterminated nested tuple")
+	}
+	return t, bz, nil
+}
+
+func decodeElement(bz []byte) (any, []byte, error) {
+	code, bz := bz[0], bz[1:]
+	switch {
+	case code == tupleNil:
+		return nil, bz, nil
+	case code == tupleBytes:
+		return decodeEscaped(bz)
+	case code == tupleString:
+		b, rest, err := decodeEscaped(bz)
+		return string(b), rest, err
+	case code == tupleNested:
+		return decodeTuple(bz, true)
+	case code >= tupleIntZero-8 && code <= tupleIntZero+8:
+		return decodeInt(code, bz)
+	case code == tupleFloat32:
+		if len(bz) < 4 {
+			return nil, nil, errors.New("short float32")
+		}
+		return DecodeFloat32(bz[:4]), bz[4:], nil
+	case code == tupleFloat64:
+		if len(bz) < 8 {
+			return nil, nil, errors.New("short float64")
+		}
+		return DecodeFloat64(bz[:8]), bz[8:], nil
+	case code == tupleFalse:
+		return false, bz, nil
+	case code == tupleTrue:
+		return true, bz, nil
+	case code == tupleUUID:
+		if len(bz) < 16 {
+			return nil, nil, errors.New("short uuid")
+		}
+		return uuid.UUID(bz[:16]), bz[16:], nil
+	case code == tupleTime:
+		if len(bz) < 8 {
+			return nil, nil, errors.New("short time")
+		}
+		return time.Unix(0, DecodeInt64(bz[:8])).UTC(), bz[8:], nil
+	default:
+		return nil, nil, fmt.Errorf("unknown type code 0x%02x", code)
+	}
+}
+
+func decodeInt(code byte, bz []byte) (any, []byte, error) {
+	neg := code < tupleIntZero
+	n := int(code) - int(tupleIntZero)
+	if neg {
+		n = -n
+	}
+	if len(bz) < n {
+		return nil, nil, errors.New("short integer")
+	}
+
+	b := make([]byte, 8)
+	copy(b[8-n:], bz[:n])
+	if neg {
+		Invert(b[8-n:])
+	}
+	u := binary.BigEndian.Uint64(b)
+
+	switch {
+	case neg && u > 1<<63:
+		return nil, nil, errors.New("integer overflows int64")
+	case neg:
+		return -int64(u), bz[n:], nil
+	case u > math.MaxInt64:
+		return u, bz[n:], nil
+	default:
+		return int64(u), bz[n:], nil
+	}
+}
+
+func decodeEscaped(bz []byte) ([]byte, []byte, error) {
+	var b []byte
+	for i := 0; i < len(bz); i++ {
+		if bz[i] != 0x00 {
+			b = append(b, bz[i])
+			continue
+		}
+		if i+1 < len(bz) && bz[i+1] == tupleEscape {
+			b = append(b, 0x00)
+			i++
+			continue
+		}
+		if b == nil {
+			b = []byte{}
+		}
+		return b, bz[i+1:], nil
+	}
+	return nil, nil, errors.New("unterminated bytes")
+}
+
+// Range returns the bounds of the keys of all of the tuples that have the tuple as their prefix, excluding
+// the tuple itself, i.e. every such key k satisfies low <= k <= high.
+func (t Tuple) Range() ([]byte, []byte, error) {
+	bz, err := t.Encode()
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(bytes.Clone(bz), 0x00), append(bz, 0xff), nil
+}
+
+// Scan assigns the elements of the tuple to the values that dst point to.
+// Elements are converted to the type of the destinations if possible and destinations that implement
+// encoding.BinaryUnmarshaler are unmarshaled from the byte slice elements.
+func (t Tuple) Scan(dst ...any) error {
+	if len(dst) != len(t) {
+		return fmt.Errorf("tuple has %d elements but %d destinations are given", len(t), len(dst))
+	}
+
+	for i, d := range dst {
+		if err := scanElement(t[i], d); err != nil {
+			return fmt.Errorf("failed to scan element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func scanElement(v any, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("destination must be a non-nil pointer, got %T", dst)
+	}
+	ev := rv.Elem()
+
+	if v == nil {
+		ev.Set(reflect.Zero(ev.Type()))
+		return nil
+	}
+
+	vv := reflect.ValueOf(v)
+	if vv.Type().AssignableTo(ev.Type()) {
+		ev.Set(vv)
+		return nil
+	}
+
+	if u, ok := dst.(encoding.BinaryUnmarshaler); ok {
+		if b, ok := v.([]byte); ok {
+			return u.UnmarshalBinary(b)
+		}
+	}
+
+	switch {
+	case ev.CanInt() && vv.CanInt():
+		if ev.OverflowInt(vv.Int()) {
+			return fmt.Errorf("%d overflows %s", vv.Int(), ev.Type())
+		}
+		ev.SetInt(vv.Int())
+	case ev.CanInt() && vv.CanUint():
+		if vv.Uint() > math.MaxInt64 || ev.OverflowInt(int64(vv.Uint())) {
+			return fmt.Errorf("%d overflows %s", vv.Uint(), ev.Type())
+		}
+		ev.SetInt(int64(vv.Uint()))
+	case ev.CanUint() && vv.CanInt():
+		if vv.Int() < 0 || ev.OverflowUint(uint64(vv.Int())) {
+			return fmt.Errorf("%d overflows %s", vv.Int(), ev.Type())
+		}
+		ev.SetUint(uint64(vv.Int()))
+	case ev.CanUint() && vv.CanUint():
+		if ev.OverflowUint(vv.Uint()) {
+			return fmt.Errorf("%d overflows %s", vv.Uint(), ev.Type())
+		}
+		ev.SetUint(vv.Uint())
+	case ev.CanFloat() && vv.CanFloat():
+		ev.SetFloat(vv.Float())
+	case vv.Type().ConvertibleTo(ev.Type()) && vv.Kind() == ev.Kind():
+		ev.Set(vv.Convert(ev.Type()))
+	default:
+		return fmt.Errorf("can't assign %T to %s", v, ev.Type())
+	}
+	return nil
+}
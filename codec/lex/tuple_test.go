@@ -0,0 +1,156 @@
+package lex_test
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuple_RoundTrip(t *testing.T) {
+	tests := []lex.Tuple{
+		{},
+		{nil},
+		{true, false},
+		{int64(0), int64(1), int64(-1), int64(255), int64(-256), int64(math.MaxInt64), int64(math.MinInt64)},
+		{uint64(math.MaxUint64)},
+		{float32(1.5), -2.25, math.Inf(-1)},
+		{"", "foo", "a\x00b"},
+		{[]byte{}, []byte{0x00, 0xff, 0x00}},
+		{lex.Tuple{}, lex.Tuple{nil, "a", lex.Tuple{int64(1), nil}}, "b"},
+		{uuid.Must(uuid.Parse("f47ac10b-58cc-4372-a567-0e02b2c3d479"))},
+		{time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		bz, err := tt.Encode()
+		require.NoError(t, err)
+
+		got, err := lex.DecodeTuple(bz)
+		require.NoError(t, err)
+		require.Equal(t, tt, got)
+	}
+}
+
+func TestTuple_Order(t *testing.T) {
+	// Tuples in ascending order.
+	tuples := []lex.Tuple{
+		{nil},
+		{[]byte{}},
+		{[]byte{0x00}},
+		{[]byte{0x00, 0x00}},
+		{[]byte{0x01}},
+		{""},
+		{"a"},
+		{"a", nil},
+		{"a", int64(1)},
+		{"a\x00"},
+		{"b"},
+		{lex.Tuple{}},
+		{lex.Tuple{nil}},
+		{lex.Tuple{int64(1)}},
+		{math.MinInt64},
+		{-256},
+		{-255},
+		{-1},
+		{0},
+		{1},
+		{uint8(255)},
+		{256},
+		{math.MaxInt64},
+		{uint64(math.MaxUint64)},
+		{float32(-1)},
+		{float32(1)},
+		{math.Inf(-1)},
+		{-1.5},
+		{0.0},
+		{1.5},
+		{false},
+		{true},
+		{uuid.UUID{}},
+		{time.Unix(-1, 0)},
+		{time.Unix(0, 0)},
+		{time.Unix(1, 0)},
+	}
+
+	encoded := make([][]byte, 0, len(tuples))
+	for _, tt := range tuples {
+		bz, err := tt.Encode()
+		require.NoError(t, err)
+		encoded = append(encoded, bz)
+	}
+
+	require.True(t, slices.IsSortedFunc(encoded, bytes.Compare))
+	for i := 1; i < len(encoded); i++ {
+		require.NotEqual(t, encoded[i-1], encoded[i], "%v and %v", tuples[i-1], tuples[i])
+	}
+}
+
+func TestTuple_Range(t *testing.T) {
+	low, high, err := lex.Tuple{"a"}.Range()
+	require.NoError(t, err)
+
+	for _, tt := range []lex.Tuple{{"a", nil}, {"a", int64(1)}, {"a", "z", true}, {"a", uuid.New()}} {
+		bz, err := tt.Encode()
+		require.NoError(t, err)
+		require.True(t, bytes.Compare(low, bz) <= 0 && bytes.Compare(bz, high) <= 0, "%v", tt)
+	}
+	for _, tt := range []lex.Tuple{{"a"}, {"ab"}, {"b"}, {nil}} {
+		bz, err := tt.Encode()
+		require.NoError(t, err)
+		require.False(t, bytes.Compare(low, bz) <= 0 && bytes.Compare(bz, high) <= 0, "%v", tt)
+	}
+}
+
+func TestTuple_Scan(t *testing.T) {
+	type name string
+
+	bz, err := lex.EncodeTuple(42, "foo", 1.5, nil, []byte{0x01}, uuid.UUID{0x01})
+	require.NoError(t, err)
+	tt, err := lex.DecodeTuple(bz)
+	require.NoError(t, err)
+
+	var (
+		i  uint16
+		n  name
+		f  float32
+		p  *int
+		b  []byte
+		id uuid.UUID
+	)
+	require.NoError(t, tt.Scan(&i, &n, &f, &p, &b, &id))
+	require.Equal(t, uint16(42), i)
+	require.Equal(t, name("foo"), n)
+	require.Equal(t, float32(1.5), f)
+	require.Nil(t, p)
+	require.Equal(t, []byte{0x01}, b)
+	require.Equal(t, uuid.UUID{0x01}, id)
+
+	var small int8
+	require.Error(t, lex.Tuple{int64(300)}.Scan(&small))
+	require.Error(t, lex.Tuple{int64(-1)}.Scan(&i))
+	require.Error(t, lex.Tuple{"foo"}.Scan(&i))
+	require.Error(t, lex.Tuple{"foo"}.Scan(i))
+	require.Error(t, lex.Tuple{"foo", "bar"}.Scan(&n))
+}
+
+func TestDecodeTuple_Invalid(t *testing.T) {
+	for _, bz := range [][]byte{
+		{0x02, 'a'},
+		{0x05, 0x02, 'a', 0x00},
+		{0x16, 0x01},
+		{0x21, 0x00},
+		{0xfe},
+	} {
+		_, err := lex.DecodeTuple(bz)
+		require.Error(t, err, "%x", bz)
+	}
+
+	_, err := lex.EncodeTuple(struct{}{})
+	require.Error(t, err)
+}
@@ -0,0 +1,238 @@
+package lex
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LexMarshaler is the interface implemented by types that can encode themselves in lexicographical order.
+type LexMarshaler interface {
+	MarshalLex() ([]byte, error)
+}
+
+// LexUnmarshaler is the interface implemented by types that can decode the lexicographical encoding of themselves.
+// The given bytes may be followed by the zero padding of fixed size index components.
+type LexUnmarshaler interface {
+	UnmarshalLex(bz []byte) error
+}
+
+// Decimal is an arbitrary-precision decimal number in its string form, e.g. "-12.5" or "1.2e-3".
+// Decimals are encoded by their values so "1.50" and "1.5" have the same encoding and decode to "1.5".
+type Decimal string
+
+var (
+	lexMarshalerType   = reflect.TypeFor[LexMarshaler]()
+	lexUnmarshalerType = reflect.TypeFor[LexUnmarshaler]()
+	timeType           = reflect.TypeFor[time.Time]()
+	bigIntType         = reflect.TypeFor[big.Int]()
+	decimalType        = reflect.TypeFor[Decimal]()
+)
+
+// Sign markers of the variable-sized numbers which sort negative numbers before zero and zero before positives.
+const (
+	signNegative byte = 0x00
+	signZero     byte = 0x01
+	signPositive byte = 0x02
+)
+
+// EncodeTime returns the byte slice representation of the given time which is its UTC nanoseconds since epoch
+// encoded in lexicographical order. Only the times between the years 1678 and 2262 are representable.
+func EncodeTime(t time.Time) []byte {
+	return EncodeInt64(t.UnixNano())
+}
+
+// DecodeTime returns the UTC time of the given byte slice which is encoded by EncodeTime.
+func DecodeTime(b []byte) time.Time {
+	return time.Unix(0, DecodeInt64(b)).UTC()
+}
+
+// EncodeBigInt returns the byte slice representation of the given integer which is encoded in lexicographical order.
+// It consists of the sign marker, the length of the magnitude and the magnitude, where both of the length and
+// the magnitude are inverted for negative numbers so that the larger magnitudes sort first.
+func EncodeBigInt(v *big.Int) []byte {
+	switch v.Sign() {
+	case 0:
+		return []byte{signZero}
+	case 1:
+		mag := v.Bytes()
+		return append(binary.BigEndian.AppendUint32([]byte{signPositive}, uint32(len(mag))), mag...)
+	default:
+		mag := v.Bytes()
+		bz := binary.BigEndian.AppendUint32([]byte{signNegative}, uint32(len(mag)))
+		bz = append(bz, mag...)
+		Invert(bz[1:])
+		return bz
+	}
+}
+
+// DecodeBigInt decodes an integer that is encoded by EncodeBigInt ignoring any trailing bytes.
+func DecodeBigInt(b []byte) (*big.Int, error) {
+	if len(b) == 0 {
+		return nil, errors.New("empty big integer")
+	}
+
+	sign := b[0]
+	switch sign {
+	case signZero:
+		return new(big.Int), nil
+	case signPositive, signNegative:
+	default:
+		return nil, fmt.Errorf("invalid sign marker 0x%02x", sign)
+	}
+	if len(b) < 5 {
+		return nil, errors.New("short big integer")
+	}
+
+	n := binary.BigEndian.Uint32(b[1:5])
+	if sign == signNegative {
+		n = ^n
+	}
+	if uint64(len(b)-5) < uint64(n) {
+		return nil, errors.New("short big integer")
+	}
+
+	mag := b[5 : 5+n]
+	if sign == signNegative {
+		mag = Invert(append([]byte{}, mag...))
+	}
+	v := new(big.Int).SetBytes(mag)
+	if sign == signNegative {
+		v.Neg(v)
+	}
+	return v, nil
+}
+
+// EncodeDecimal returns the byte slice representation of the given decimal number which is encoded in
+// lexicographical order. It consists of the sign marker, the exponent of the number in the scientific notation
+// with a leading zero (0.d1d2...) and its significant digits followed by a terminator, where both of the
+// exponent and the digits are inverted for negative numbers so that the larger magnitudes sort first.
+func EncodeDecimal(d Decimal) ([]byte, error) {
+	neg, digits, exp, err := parseDecimal(string(d))
+	if err != nil {
+		return nil, err
+	}
+	if digits == "" {
+		return []byte{signZero}, nil
+	}
+	if exp > math.MaxInt32 || exp < math.MinInt32 {
+		return nil, fmt.Errorf("exponent of decimal %q is out of range", d)
+	}
+
+	bz := append(EncodeInt32(int32(exp)), digits...)
+	if !neg {
+		return append(append([]byte{signPositive}, bz...), 0x00), nil
+	}
+	return append(append([]byte{signNegative}, Invert(bz)...), 0xff), nil
+}
+
+// DecodeDecimal decodes a decimal number that is encoded by EncodeDecimal ignoring any trailing bytes.
+// The result is in its canonical form without the exponent and the insignificant zeros.
+func DecodeDecimal(b []byte) (Decimal, error) {
+	if len(b) == 0 {
+		return "", errors.New("empty decimal")
+	}
+
+	sign := b[0]
+	switch sign {
+	case signZero:
+		return "0", nil
+	case signPositive, signNegative:
+	default:
+		return "", fmt.Errorf("invalid sign marker 0x%02x", sign)
+	}
+	if len(b) < 5 {
+		return "", errors.New("short decimal")
+	}
+
+	neg := sign == signNegative
+	terminator := byte(0x00)
+	if neg {
+		terminator = 0xff
+	}
+	i := 5
+	for i < len(b) && b[i] != terminator {
+		i++
+	}
+	if i == len(b) {
+		return "", errors.New("unterminated decimal")
+	}
+
+	bz := append([]byte{}, b[1:i]...)
+	if neg {
+		Invert(bz)
+	}
+	exp := int(DecodeInt32(bz[:4]))
+	digits := string(bz[4:])
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("invalid digit %q", c)
+		}
+	}
+
+	var sb strings.Builder
+	if neg {
+		sb.WriteByte('-')
+	}
+	switch {
+	case exp <= 0:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -exp))
+		sb.WriteString(digits)
+	case exp >= len(digits):
+		sb.WriteString(digits)
+		sb.WriteString(strings.Repeat("0", exp-len(digits)))
+	default:
+		sb.WriteString(digits[:exp])
+		sb.WriteByte('.')
+		sb.WriteString(digits[exp:])
+	}
+	return Decimal(sb.String()), nil
+}
+
+// parseDecimal parses a decimal number into its sign, significant digits without leading and trailing zeros and
+// the exponent e such that the value is 0.digits * 10^e. The digits are empty for zero.
+func parseDecimal(s string) (bool, string, int, error) {
+	orig := s
+	var neg bool
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return false, "", 0, fmt.Errorf("invalid decimal %q: %w", orig, err)
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return false, "", 0, fmt.Errorf("invalid decimal %q", orig)
+	}
+	for _, c := range intPart + fracPart {
+		if c < '0' || c > '9' {
+			return false, "", 0, fmt.Errorf("invalid decimal %q", orig)
+		}
+	}
+
+	digits := intPart + fracPart
+	exp += len(intPart)
+	trimmed := strings.TrimLeft(digits, "0")
+	exp -= len(digits) - len(trimmed)
+	digits = strings.TrimRight(trimmed, "0")
+	if digits == "" {
+		return false, "", 0, nil
+	}
+	return neg, digits, exp, nil
+}
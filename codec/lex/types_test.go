@@ -0,0 +1,167 @@
+package lex_test
+
+import (
+	"bytes"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBigInt(t *testing.T) {
+	// Integers in ascending order.
+	values := []string{
+		"-100000000000000000000000",
+		"-65536",
+		"-65535",
+		"-256",
+		"-255",
+		"-1",
+		"0",
+		"1",
+		"255",
+		"256",
+		"65535",
+		"100000000000000000000000",
+	}
+
+	encoded := make([][]byte, 0, len(values))
+	for _, s := range values {
+		v, ok := new(big.Int).SetString(s, 10)
+		require.True(t, ok)
+
+		bz := lex.EncodeBigInt(v)
+		encoded = append(encoded, bz)
+
+		got, err := lex.DecodeBigInt(append(bz, 0x00, 0x00))
+		require.NoError(t, err)
+		require.Equal(t, s, got.String())
+	}
+	require.True(t, slices.IsSortedFunc(encoded, bytes.Compare))
+
+	_, err := lex.DecodeBigInt([]byte{0x02, 0x00, 0x00, 0x00, 0x02, 0x01})
+	require.Error(t, err)
+}
+
+func TestEncodeDecimal(t *testing.T) {
+	// Decimals in ascending order with their canonical forms.
+	values := []struct {
+		input     lex.Decimal
+		canonical lex.Decimal
+	}{
+		{"-1e3", "-1000"},
+		{"-12.5", "-12.5"},
+		{"-1.23", "-1.23"},
+		{"-1.2", "-1.2"},
+		{"-0.001", "-0.001"},
+		{"0", "0"},
+		{"-0.00", "0"},
+		{"0.001", "0.001"},
+		{".5", "0.5"},
+		{"1.20", "1.2"},
+		{"1.23", "1.23"},
+		{"+12.5", "12.5"},
+		{"99", "99"},
+		{"100", "100"},
+		{"1.5E10", "15000000000"},
+	}
+
+	encoded := make([][]byte, 0, len(values))
+	for _, v := range values {
+		bz, err := lex.EncodeDecimal(v.input)
+		require.NoError(t, err, v.input)
+		encoded = append(encoded, bz)
+
+		got, err := lex.DecodeDecimal(append(bz, 0x00))
+		require.NoError(t, err)
+		require.Equal(t, v.canonical, got)
+	}
+	require.True(t, slices.IsSortedFunc(encoded, bytes.Compare))
+
+	for _, d := range []lex.Decimal{"", "-", "1.2.3", "abc", "1e", "0x10"} {
+		_, err := lex.EncodeDecimal(d)
+		require.Error(t, err, d)
+	}
+}
+
+type reversed string
+
+func (r reversed) MarshalLex() ([]byte, error) {
+	return lex.Invert([]byte(r)), nil
+}
+
+func (r *reversed) UnmarshalLex(bz []byte) error {
+	*r = reversed(lex.Invert(bytes.Clone(bz)))
+	return nil
+}
+
+func TestEncoder_ExtendedTypes(t *testing.T) {
+	enc, dec := &lex.Encoder{}, &lex.Decoder{}
+
+	t.Run("Time", func(t *testing.T) {
+		v := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+		bz, err := enc.Encode(v)
+		require.NoError(t, err)
+		require.Equal(t, lex.EncodeInt64(v.UnixNano()), bz)
+
+		later, err := enc.Encode(v.Add(time.Nanosecond))
+		require.NoError(t, err)
+		require.Negative(t, bytes.Compare(bz, later))
+
+		var got time.Time
+		require.NoError(t, dec.Decode(bz, &got))
+		require.Equal(t, v, got)
+	})
+
+	t.Run("Byte array", func(t *testing.T) {
+		v := uuid.New()
+		bz, err := enc.Encode(v)
+		require.NoError(t, err)
+		require.Equal(t, v[:], bz)
+
+		var got uuid.UUID
+		require.NoError(t, dec.Decode(bz, &got))
+		require.Equal(t, v, got)
+	})
+
+	t.Run("Big integer", func(t *testing.T) {
+		v := big.NewInt(-42)
+		bz, err := enc.Encode(v)
+		require.NoError(t, err)
+		require.Equal(t, lex.EncodeBigInt(v), bz)
+
+		var got *big.Int
+		require.NoError(t, dec.Decode(bz, &got))
+		require.Equal(t, 0, v.Cmp(got))
+	})
+
+	t.Run("Decimal", func(t *testing.T) {
+		bz, err := enc.Encode(lex.Decimal("3.14"))
+		require.NoError(t, err)
+
+		var got lex.Decimal
+		require.NoError(t, dec.Decode(bz, &got))
+		require.Equal(t, lex.Decimal("3.14"), got)
+	})
+
+	t.Run("LexMarshaler", func(t *testing.T) {
+		bz, err := enc.Encode(reversed("ab"))
+		require.NoError(t, err)
+		require.Equal(t, lex.Invert([]byte("ab")), bz)
+
+		var got reversed
+		require.NoError(t, dec.Decode(bz, &got))
+		require.Equal(t, reversed("ab"), got)
+	})
+
+	t.Run("Unsupported struct", func(t *testing.T) {
+		_, err := enc.Encode(struct{ A int }{})
+		require.Error(t, err)
+		require.True(t, strings.Contains(err.Error(), "unsupported type"))
+	})
+}
@@ -0,0 +1,128 @@
+package lex
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// varintZero is the header of the zero which separates the headers of negative and positive values.
+// Positive values with n magnitude bytes have the header varintZero+n and negative values have the header
+// varintZero-1-n followed by the inverted magnitude bytes, so the headers are in range [0x77, 0x88].
+const varintZero = 0x80
+
+// MaxVarintLen is the maximum length of the varint encoded integers.
+const MaxVarintLen = 1 + 8
+
+// EncodeVarint returns the variable-length byte slice representation of the given int64 which is
+// encoded in lexicographical order. Small values take fewer bytes, e.g. values in [-256, 255] take at most 2 bytes.
+func EncodeVarint(v int64) []byte {
+	return AppendVarint(nil, v)
+}
+
+// AppendVarint appends the varint encoding of v to b.
+func AppendVarint(b []byte, v int64) []byte {
+	if v >= 0 {
+		return AppendUvarint(b, uint64(v))
+	}
+
+	// The magnitude of ^v is |v|-1 so that -1 is encoded as the header alone.
+	m := uint64(^v)
+	n := varintLen(m)
+	b = append(b, byte(varintZero-1-n))
+	return appendMagnitude(b, ^m, n)
+}
+
+// EncodeUvarint returns the variable-length byte slice representation of the given uint64 which is
+// encoded in lexicographical order. The encoding is the same as EncodeVarint for non-negative values.
+func EncodeUvarint(v uint64) []byte {
+	return AppendUvarint(nil, v)
+}
+
+// AppendUvarint appends the uvarint encoding of v to b.
+func AppendUvarint(b []byte, v uint64) []byte {
+	n := varintLen(v)
+	b = append(b, byte(varintZero+n))
+	return appendMagnitude(b, v, n)
+}
+
+func varintLen(m uint64) int {
+	return (bits.Len64(m) + 7) / 8
+}
+
+// appendMagnitude appends the n least significant bytes of m in big-endian order.
+func appendMagnitude(b []byte, m uint64, n int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], m)
+	return append(b, buf[8-n:]...)
+}
+
+// VarintLen returns the length of the varint at the beginning of b or 0 if b doesn't start with a valid varint.
+func VarintLen(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+
+	h := int(b[0])
+	var n int
+	switch {
+	case h >= varintZero && h <= varintZero+8:
+		n = h - varintZero
+	case h < varintZero && h >= varintZero-1-8:
+		n = varintZero - 1 - h
+	default:
+		return 0
+	}
+	if len(b) < 1+n {
+		return 0
+	}
+	return 1 + n
+}
+
+// DecodeVarint decodes the varint at the beginning of b and returns it with the number of bytes read.
+// The number of bytes is 0 if b doesn't start with a valid varint and negative if the value overflows int64.
+func DecodeVarint(b []byte) (int64, int) {
+	n := VarintLen(b)
+	if n == 0 {
+		return 0, 0
+	}
+
+	if b[0] < varintZero {
+		var m uint64
+		for _, c := range b[1:n] {
+			m = m<<8 | uint64(^c)
+		}
+		if m > math.MaxInt64 {
+			return 0, -n
+		}
+		return ^int64(m), n
+	}
+
+	m := readMagnitude(b[1:n])
+	if m > math.MaxInt64 {
+		return 0, -n
+	}
+	return int64(m), n
+}
+
+// DecodeUvarint decodes the uvarint at the beginning of b and returns it with the number of bytes read.
+// The number of bytes is 0 if b doesn't start with a valid varint and negative if the value is negative.
+func DecodeUvarint(b []byte) (uint64, int) {
+	n := VarintLen(b)
+	if n == 0 {
+		return 0, 0
+	}
+	if b[0] < varintZero {
+		return 0, -n
+	}
+
+	return readMagnitude(b[1:n]), n
+}
+
+func readMagnitude(b []byte) uint64 {
+	var m uint64
+	for _, c := range b {
+		m = m<<8 | uint64(c)
+	}
+	return m
+}
@@ -0,0 +1,79 @@
+package lex_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeVarint(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected []byte
+	}{
+		{math.MinInt64, []byte{0x77, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{-257, []byte{0x7d, 0xfe, 0xff}},
+		{-256, []byte{0x7e, 0x00}},
+		{-2, []byte{0x7e, 0xfe}},
+		{-1, []byte{0x7f}},
+		{0, []byte{0x80}},
+		{1, []byte{0x81, 0x01}},
+		{255, []byte{0x81, 0xff}},
+		{256, []byte{0x82, 0x01, 0x00}},
+		{math.MaxInt64, []byte{0x88, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for i, test := range tests {
+		bz := lex.EncodeVarint(test.input)
+		require.Equal(t, test.expected, bz, "EncodeVarint(%d)", test.input)
+		if i > 0 {
+			require.Negative(t, bytes.Compare(tests[i-1].expected, bz))
+		}
+
+		v, n := lex.DecodeVarint(append(bz, 0xaa))
+		require.Equal(t, len(bz), n)
+		require.Equal(t, test.input, v)
+	}
+}
+
+func TestEncodeUvarint(t *testing.T) {
+	values := []uint64{0, 1, 255, 256, 1 << 32, math.MaxInt64, math.MaxUint64}
+
+	for i, v := range values {
+		bz := lex.EncodeUvarint(v)
+		if v <= math.MaxInt64 {
+			require.Equal(t, lex.EncodeVarint(int64(v)), bz)
+		}
+		if i > 0 {
+			require.Negative(t, bytes.Compare(lex.EncodeUvarint(values[i-1]), bz))
+		}
+
+		got, n := lex.DecodeUvarint(bz)
+		require.Equal(t, len(bz), n)
+		require.Equal(t, v, got)
+	}
+}
+
+func TestDecodeVarint_Invalid(t *testing.T) {
+	tests := []struct {
+		input []byte
+		n     int
+	}{
+		{nil, 0},
+		{[]byte{0x00}, 0},
+		{[]byte{0xff}, 0},
+		{[]byte{0x82, 0x01}, 0},
+		{lex.EncodeUvarint(math.MaxUint64), -9},
+	}
+
+	for _, test := range tests {
+		_, n := lex.DecodeVarint(test.input)
+		require.Equal(t, test.n, n, "DecodeVarint(%x)", test.input)
+	}
+
+	_, n := lex.DecodeUvarint(lex.EncodeVarint(-1))
+	require.Equal(t, -1, n)
+}
@@ -0,0 +1,148 @@
+package codec
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/google/uuid"
+)
+
+// tupleCodec encodes the exported fields of structs as an order-preserving lex.Tuple.
+// Nested structs are encoded as nested tuples.
+type tupleCodec[T any] struct{}
+
+// Encode implements the Codec interface.
+func (tupleCodec[T]) Encode(v T) ([]byte, error) {
+	t, err := structToTuple(reflect.ValueOf(&v).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return t.Encode()
+}
+
+// Decode implements the Codec interface.
+func (tupleCodec[T]) Decode(bz []byte) (T, error) {
+	var v T
+	t, err := lex.DecodeTuple(bz)
+	if err != nil {
+		return v, err
+	}
+
+	err = tupleToStruct(t, reflect.ValueOf(&v).Elem())
+	return v, err
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	uuidType            = reflect.TypeFor[uuid.UUID]()
+	binaryMarshalerType = reflect.TypeFor[encoding.BinaryMarshaler]()
+)
+
+func structToTuple(rv reflect.Value) (lex.Tuple, error) {
+	t := make(lex.Tuple, 0, rv.NumField())
+	for i := 0; i < rv.NumField(); i++ {
+		if !rv.Type().Field(i).IsExported() {
+			continue
+		}
+
+		e, err := tupleElement(rv.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode field %s: %w", rv.Type().Field(i).Name, err)
+		}
+		t = append(t, e)
+	}
+	return t, nil
+}
+
+// tupleElement converts a field value to a value that lex.Tuple supports.
+func tupleElement(rv reflect.Value) (any, error) {
+	switch {
+	case rv.Type() == timeType || rv.Type() == uuidType:
+		return rv.Interface(), nil
+	case rv.Kind() == reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return tupleElement(rv.Elem())
+	case rv.Kind() == reflect.Struct && !rv.Type().Implements(binaryMarshalerType):
+		return structToTuple(rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32:
+		return float32(rv.Float()), nil
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			bz := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(bz), rv)
+			return bz, nil
+		}
+	}
+
+	if rv.Type().Implements(binaryMarshalerType) {
+		return rv.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", rv.Type())
+}
+
+func tupleToStruct(t lex.Tuple, rv reflect.Value) error {
+	var n int
+	for i := 0; i < rv.NumField(); i++ {
+		if !rv.Type().Field(i).IsExported() {
+			continue
+		}
+		if n >= len(t) {
+			return fmt.Errorf("tuple has %d elements but %s has more fields", len(t), rv.Type())
+		}
+
+		if err := setTupleElement(t[n], rv.Field(i)); err != nil {
+			return fmt.Errorf("failed to decode field %s: %w", rv.Type().Field(i).Name, err)
+		}
+		n++
+	}
+	if n != len(t) {
+		return fmt.Errorf("tuple has %d elements but %s has %d fields", len(t), rv.Type(), n)
+	}
+	return nil
+}
+
+func setTupleElement(e any, rv reflect.Value) error {
+	if rv.Kind() == reflect.Pointer {
+		if e == nil {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		rv.Set(reflect.New(rv.Type().Elem()))
+		rv = rv.Elem()
+	}
+
+	if nested, ok := e.(lex.Tuple); ok && rv.Kind() == reflect.Struct {
+		return tupleToStruct(nested, rv)
+	}
+	if bz, ok := e.([]byte); ok && rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		if len(bz) != rv.Len() {
+			return fmt.Errorf("invalid length %d for %s", len(bz), rv.Type())
+		}
+		reflect.Copy(rv, reflect.ValueOf(bz))
+		return nil
+	}
+
+	return lex.Tuple{e}.Scan(rv.Addr().Interface())
+}
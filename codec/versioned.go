@@ -0,0 +1,153 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// versionedMagic is the first byte of the values written by VersionedCodec.
+const versionedMagic = 0xe2
+
+// Upgradable is implemented by codecs that upgrade the values written in older formats while decoding.
+// Stores use it to write the upgraded values back and to migrate the stale values in bulk.
+type Upgradable interface {
+	// IsStale reports whether the value is written in an older format.
+	IsStale(bz []byte) (bool, error)
+	// WriteBack reports whether the stale values should be written back in the latest format after they're read.
+	WriteBack() bool
+}
+
+// VersionedCodec is a codec that prefixes the output of another codec with a schema version and
+// upgrades the values written with older versions using a chain of upgraders before decoding them.
+// Values that don't start with the version header (e.g. written before versioning was enabled)
+// are considered to be of version 0, which is safe for JSON and protobuf encoded values.
+type VersionedCodec[T any] struct {
+	codec     Codec[T]
+	version   uint32
+	upgraders map[uint32]func(old []byte) ([]byte, error)
+	writeBack bool
+}
+
+// Versioned creates a new VersionedCodec that writes values encoded by c with the given version.
+func Versioned[T any](c Codec[T], version uint32) *VersionedCodec[T] {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+
+	return &VersionedCodec[T]{
+		codec:     c,
+		version:   version,
+		upgraders: map[uint32]func([]byte) ([]byte, error){},
+	}
+}
+
+// WithUpgrader registers the upgrader that converts values of version from to version from+1.
+func (c *VersionedCodec[T]) WithUpgrader(from uint32, fn func(old []byte) ([]byte, error)) *VersionedCodec[T] {
+	if from >= c.version {
+		panic(fmt.Sprintf("upgrader from version %d must be less than current version %d", from, c.version))
+	}
+	if _, ok := c.upgraders[from]; ok {
+		panic(fmt.Sprintf("upgrader from version %d already exists", from))
+	}
+
+	c.upgraders[from] = fn
+	return c
+}
+
+// WithWriteBack makes the stores write the upgraded values back in the latest version after they're read.
+func (c *VersionedCodec[T]) WithWriteBack() *VersionedCodec[T] {
+	c.writeBack = true
+	return c
+}
+
+// Version returns the current version of the codec.
+func (c *VersionedCodec[T]) Version() uint32 {
+	return c.version
+}
+
+// Encode implements the Codec interface.
+func (c *VersionedCodec[T]) Encode(v T) ([]byte, error) {
+	bz, err := c.codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 1+binary.MaxVarintLen32+len(bz))
+	out = append(out, versionedMagic)
+	out = binary.AppendUvarint(out, uint64(c.version))
+	return append(out, bz...), nil
+}
+
+// Decode implements the Codec interface.
+func (c *VersionedCodec[T]) Decode(bz []byte) (T, error) {
+	var zero T
+
+	version, bz, err := SplitVersion(bz)
+	if err != nil {
+		return zero, err
+	}
+	if version > c.version {
+		return zero, fmt.Errorf("version %d is newer than current version %d", version, c.version)
+	}
+
+	for ; version < c.version; version++ {
+		fn, ok := c.upgraders[version]
+		if !ok {
+			return zero, fmt.Errorf("no upgrader from version %d", version)
+		}
+
+		bz, err = fn(bz)
+		if err != nil {
+			return zero, fmt.Errorf("failed to upgrade value from version %d: %w", version, err)
+		}
+	}
+
+	return c.codec.Decode(bz)
+}
+
+// IsStale implements the Upgradable interface.
+func (c *VersionedCodec[T]) IsStale(bz []byte) (bool, error) {
+	version, _, err := SplitVersion(bz)
+	if err != nil {
+		return false, err
+	}
+
+	return version < c.version, nil
+}
+
+// WriteBack implements the Upgradable interface.
+func (c *VersionedCodec[T]) WriteBack() bool {
+	return c.writeBack
+}
+
+// SplitVersion splits a value written by VersionedCodec into its version and the encoded value.
+func SplitVersion(bz []byte) (uint32, []byte, error) {
+	if len(bz) == 0 || bz[0] != versionedMagic {
+		return 0, bz, nil
+	}
+
+	version, n := binary.Uvarint(bz[1:])
+	if n <= 0 || version > 1<<32-1 {
+		return 0, nil, fmt.Errorf("invalid version header")
+	}
+
+	return uint32(version), bz[1+n:], nil
+}
+
+// isStale reports whether the value decoded from bz by the inner codec c is stale.
+func isStale[T any](c Codec[T], bz []byte) (bool, error) {
+	if u, ok := c.(Upgradable); ok {
+		return u.IsStale(bz)
+	}
+
+	return false, nil
+}
+
+// writeBack reports whether the inner codec c wants the stale values to be written back.
+func writeBack[T any](c Codec[T]) bool {
+	if u, ok := c.(Upgradable); ok {
+		return u.WriteBack()
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package badgerutils
+
+import (
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// EntryOptions are the options of the badger.Entry written by the stores.
+type EntryOptions struct {
+	// ExpiresAt is the unix time in seconds that the entry expires at, zero means it never expires.
+	ExpiresAt uint64
+	// UserMeta is the user metadata of the entry.
+	UserMeta byte
+	// Discard marks the older versions of the entry to be discarded on compaction.
+	Discard bool
+}
+
+// EntryOption is an option of the entries written by the stores.
+type EntryOption func(*EntryOptions)
+
+// WithTTL makes the entry expire after d. The expiry time is computed once when the option is created,
+// so all the entries written with the same option expire at the same time.
+func WithTTL(d time.Duration) EntryOption {
+	return WithExpiresAt(uint64(time.Now().Add(d).Unix()))
+}
+
+// WithExpiresAt makes the entry expire at the given unix time in seconds.
+func WithExpiresAt(ts uint64) EntryOption {
+	return func(o *EntryOptions) {
+		o.ExpiresAt = ts
+	}
+}
+
+// WithMeta sets the user metadata of the entry.
+func WithMeta(meta byte) EntryOption {
+	return func(o *EntryOptions) {
+		o.UserMeta = meta
+	}
+}
+
+// WithDiscard marks the older versions of the entry to be discarded on compaction.
+func WithDiscard() EntryOption {
+	return func(o *EntryOptions) {
+		o.Discard = true
+	}
+}
+
+// WithEntryOptions overrides all the options of the entry with o, e.g. the ones returned by FindEntryOptions.
+func WithEntryOptions(o EntryOptions) EntryOption {
+	return func(p *EntryOptions) {
+		*p = o
+	}
+}
+
+// NewEntryOptions applies the given options to a zero EntryOptions.
+func NewEntryOptions(opts ...EntryOption) EntryOptions {
+	var o EntryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// FindEntryOptions applies the EntryOption values among the given arbitrary options, e.g. the ones passed to
+// the extensions, and reports whether there was any.
+func FindEntryOptions(opts []any) (EntryOptions, bool) {
+	var (
+		o     EntryOptions
+		found bool
+	)
+	for _, opt := range opts {
+		if eo, ok := opt.(EntryOption); ok {
+			eo(&o)
+			found = true
+		}
+	}
+	return o, found
+}
+
+// NewEntry creates a new badger.Entry with the options applied.
+func (o EntryOptions) NewEntry(key, value []byte) *badger.Entry {
+	e := badger.NewEntry(key, value)
+	e.ExpiresAt = o.ExpiresAt
+	if o.UserMeta != 0 {
+		e = e.WithMeta(o.UserMeta)
+	}
+	if o.Discard {
+		e = e.WithDiscard()
+	}
+	return e
+}
@@ -0,0 +1,195 @@
+package expr
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Comparator compares two values and returns -1, 0 or +1 if a is less than, equal to or greater than b.
+type Comparator[T any] func(a, b T) int
+
+// Ordered returns the comparator of an ordered type.
+func Ordered[T cmp.Ordered]() Comparator[T] {
+	return cmp.Compare[T]
+}
+
+// IsEmpty returns true if no value can fall in the range.
+// Ranges of discrete types like (1, 2) are not detected as empty since the comparator can't tell about it.
+func (r Range[T]) IsEmpty(c Comparator[T]) bool {
+	if r.low.IsEmpty() || r.high.IsEmpty() {
+		return false
+	}
+
+	n := c(r.low.value, r.high.value)
+	return n > 0 || (n == 0 && (r.low.exclusive || r.high.exclusive))
+}
+
+// Contains returns true if the value falls in the range.
+func (r Range[T]) Contains(c Comparator[T], v T) bool {
+	if !r.low.IsEmpty() {
+		n := c(r.low.value, v)
+		if n > 0 || (n == 0 && r.low.exclusive) {
+			return false
+		}
+	}
+	if !r.high.IsEmpty() {
+		n := c(v, r.high.value)
+		if n > 0 || (n == 0 && r.high.exclusive) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Overlaps returns true if there is any value that falls in both of the ranges.
+func (r Range[T]) Overlaps(c Comparator[T], o Range[T]) bool {
+	return !r.Intersect(c, o).IsEmpty(c)
+}
+
+// Intersect returns the range of the values that fall in both of the ranges which may be empty.
+func (r Range[T]) Intersect(c Comparator[T], o Range[T]) Range[T] {
+	low, high := r.low, r.high
+	if compareLows(c, o.low, low) > 0 {
+		low = o.low
+	}
+	if compareHighs(c, o.high, high) < 0 {
+		high = o.high
+	}
+
+	return NewRange(low, high)
+}
+
+// Union returns the ranges of the values that fall in any of the ranges.
+// It returns a single range if the ranges overlap or are adjacent, otherwise both of them in ascending order.
+// Empty ranges are omitted.
+func (r Range[T]) Union(c Comparator[T], o Range[T]) []Range[T] {
+	return NormalizeRanges(c, r, o)
+}
+
+// Complement returns the ranges of the values that don't fall in the range in ascending order.
+func (r Range[T]) Complement(c Comparator[T]) []Range[T] {
+	return ComplementRanges(c, r)
+}
+
+// NormalizeRanges drops the empty ranges, sorts the rest by their low bounds and merges the overlapping and
+// adjacent ones so that the result is a list of disjoint ranges in ascending order.
+func NormalizeRanges[T any](c Comparator[T], ranges ...Range[T]) []Range[T] {
+	rs := make([]Range[T], 0, len(ranges))
+	for _, r := range ranges {
+		if !r.IsEmpty(c) {
+			rs = append(rs, r)
+		}
+	}
+	slices.SortStableFunc(rs, func(a, b Range[T]) int {
+		return compareLows(c, a.low, b.low)
+	})
+
+	result := make([]Range[T], 0, len(rs))
+	for _, r := range rs {
+		if n := len(result); n > 0 && touches(c, result[n-1].high, r.low) {
+			if compareHighs(c, r.high, result[n-1].high) > 0 {
+				result[n-1] = NewRange(result[n-1].low, r.high)
+			}
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// IntersectRanges returns the normalized ranges of the values that fall in both of the given range sets.
+func IntersectRanges[T any](c Comparator[T], a, b []Range[T]) []Range[T] {
+	a, b = NormalizeRanges(c, a...), NormalizeRanges(c, b...)
+
+	var result []Range[T]
+	for _, x := range a {
+		for _, y := range b {
+			result = append(result, x.Intersect(c, y))
+		}
+	}
+	return NormalizeRanges(c, result...)
+}
+
+// ComplementRanges returns the normalized ranges of the values that don't fall in any of the given ranges.
+func ComplementRanges[T any](c Comparator[T], ranges ...Range[T]) []Range[T] {
+	ranges = NormalizeRanges(c, ranges...)
+	if len(ranges) == 0 {
+		return []Range[T]{NewRange[T](nil, nil)}
+	}
+
+	var (
+		result []Range[T]
+		low    *Bound[T]
+	)
+	for _, r := range ranges {
+		if !r.low.IsEmpty() {
+			result = append(result, NewRange(low, NewBound(r.low.value, !r.low.exclusive)))
+		}
+		if r.high.IsEmpty() {
+			return NormalizeRanges(c, result...)
+		}
+		low = NewBound(r.high.value, !r.high.exclusive)
+	}
+	result = append(result, NewRange(low, nil))
+
+	return NormalizeRanges(c, result...)
+}
+
+// compareLows compares two low bounds where an empty bound is the lowest.
+func compareLows[T any](c Comparator[T], a, b *Bound[T]) int {
+	switch {
+	case a.IsEmpty() && b.IsEmpty():
+		return 0
+	case a.IsEmpty():
+		return -1
+	case b.IsEmpty():
+		return 1
+	}
+
+	if n := c(a.value, b.value); n != 0 {
+		return n
+	}
+	switch {
+	case a.exclusive == b.exclusive:
+		return 0
+	case a.exclusive:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// compareHighs compares two high bounds where an empty bound is the highest.
+func compareHighs[T any](c Comparator[T], a, b *Bound[T]) int {
+	switch {
+	case a.IsEmpty() && b.IsEmpty():
+		return 0
+	case a.IsEmpty():
+		return 1
+	case b.IsEmpty():
+		return -1
+	}
+
+	if n := c(a.value, b.value); n != 0 {
+		return n
+	}
+	switch {
+	case a.exclusive == b.exclusive:
+		return 0
+	case a.exclusive:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// touches reports whether a range ending at high overlaps or is adjacent to a range starting at low.
+func touches[T any](c Comparator[T], high, low *Bound[T]) bool {
+	if high.IsEmpty() || low.IsEmpty() {
+		return true
+	}
+
+	n := c(high.value, low.value)
+	return n > 0 || (n == 0 && !(high.exclusive && low.exclusive))
+}
@@ -0,0 +1,135 @@
+package expr_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func closed(low, high int) expr.Range[int] {
+	return expr.NewRange(expr.NewBound(low, false), expr.NewBound(high, false))
+}
+
+func open(low, high int) expr.Range[int] {
+	return expr.NewRange(expr.NewBound(low, true), expr.NewBound(high, true))
+}
+
+func TestRange_IsEmpty(t *testing.T) {
+	c := expr.Ordered[int]()
+
+	tests := []struct {
+		r        expr.Range[int]
+		expected bool
+	}{
+		{r: closed(1, 2), expected: false},
+		{r: closed(1, 1), expected: false},
+		{r: open(1, 1), expected: true},
+		{r: expr.NewRange(expr.NewBound(1, false), expr.NewBound(1, true)), expected: true},
+		{r: closed(2, 1), expected: true},
+		{r: expr.NewRange[int](nil, nil), expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.r.String(), func(t *testing.T) {
+			require.Equal(t, test.expected, test.r.IsEmpty(c))
+		})
+	}
+}
+
+func TestRange_Contains(t *testing.T) {
+	c := expr.Ordered[int]()
+
+	require.True(t, closed(1, 3).Contains(c, 1))
+	require.True(t, closed(1, 3).Contains(c, 3))
+	require.False(t, open(1, 3).Contains(c, 1))
+	require.False(t, open(1, 3).Contains(c, 3))
+	require.True(t, open(1, 3).Contains(c, 2))
+	require.False(t, closed(1, 3).Contains(c, 4))
+	require.True(t, expr.NewRange(nil, expr.NewBound(3, true)).Contains(c, -100))
+	require.True(t, expr.NewRange(expr.NewBound(1, true), nil).Contains(c, 100))
+}
+
+func TestRange_Intersect(t *testing.T) {
+	c := expr.Ordered[int]()
+
+	tests := []struct {
+		name     string
+		a, b     expr.Range[int]
+		expected expr.Range[int]
+		overlaps bool
+	}{
+		{
+			name:     "Overlapping",
+			a:        closed(1, 5),
+			b:        open(3, 7),
+			expected: expr.NewRange(expr.NewBound(3, true), expr.NewBound(5, false)),
+			overlaps: true,
+		},
+		{
+			name:     "Touching inclusive",
+			a:        closed(1, 3),
+			b:        closed(3, 5),
+			expected: closed(3, 3),
+			overlaps: true,
+		},
+		{
+			name:     "Touching exclusive",
+			a:        expr.NewRange(expr.NewBound(1, false), expr.NewBound(3, true)),
+			b:        closed(3, 5),
+			expected: expr.NewRange(expr.NewBound(3, false), expr.NewBound(3, true)),
+			overlaps: false,
+		},
+		{
+			name:     "Unbounded",
+			a:        expr.NewRange[int](nil, nil),
+			b:        expr.NewRange(nil, expr.NewBound(3, true)),
+			expected: expr.NewRange(nil, expr.NewBound(3, true)),
+			overlaps: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.a.Intersect(c, test.b))
+			require.Equal(t, test.expected, test.b.Intersect(c, test.a))
+			require.Equal(t, test.overlaps, test.a.Overlaps(c, test.b))
+		})
+	}
+}
+
+func TestRange_Union(t *testing.T) {
+	c := expr.Ordered[int]()
+
+	require.Equal(t, []expr.Range[int]{closed(1, 7)}, closed(3, 7).Union(c, closed(1, 5)))
+	require.Equal(t, []expr.Range[int]{closed(1, 5)}, closed(1, 3).Union(c, closed(3, 5)))
+	require.Equal(t, []expr.Range[int]{open(1, 3), open(3, 5)}, open(3, 5).Union(c, open(1, 3)))
+	require.Equal(t, []expr.Range[int]{closed(1, 3)}, closed(1, 3).Union(c, open(4, 4)))
+}
+
+func TestRange_Complement(t *testing.T) {
+	c := expr.Ordered[int]()
+
+	require.Equal(t, []expr.Range[int]{
+		expr.NewRange(nil, expr.NewBound(5, true)),
+		expr.NewRange(expr.NewBound(5, true), nil),
+	}, closed(5, 5).Complement(c))
+	require.Equal(t, []expr.Range[int]{
+		expr.NewRange(nil, expr.NewBound(1, false)),
+	}, expr.NewRange(expr.NewBound(1, true), nil).Complement(c))
+	require.Empty(t, expr.NewRange[int](nil, nil).Complement(c))
+	require.Equal(t, []expr.Range[int]{expr.NewRange[int](nil, nil)}, open(1, 1).Complement(c))
+}
+
+func TestIntersectRanges(t *testing.T) {
+	c := expr.Comparator[[]byte](bytes.Compare)
+	r := func(low, high string) expr.Range[[]byte] {
+		return expr.NewRange(expr.NewBound([]byte(low), false), expr.NewBound([]byte(high), false))
+	}
+
+	require.Equal(t,
+		[]expr.Range[[]byte]{r("b", "c"), r("e", "f")},
+		expr.IntersectRanges(c, []expr.Range[[]byte]{r("a", "c"), r("e", "g")}, []expr.Range[[]byte]{r("b", "f")}),
+	)
+}
@@ -0,0 +1,488 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Values of interface types, e.g. Range[any], are marshaled along with their type so that they can be unmarshaled
+// to the same type, i.e. {"type": "int64", "value": 5}. Only the builtin scalar types, strings and byte slices are
+// supported. Nested expressions, e.g. the expression of Assigned, are marshaled along with their kind,
+// i.e. {"kind": "range", "expr": {...}}, and are unmarshaled to their any instantiation, e.g. Range[any].
+
+var valueTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeFor[bool](),
+	"int":     reflect.TypeFor[int](),
+	"int8":    reflect.TypeFor[int8](),
+	"int16":   reflect.TypeFor[int16](),
+	"int32":   reflect.TypeFor[int32](),
+	"int64":   reflect.TypeFor[int64](),
+	"uint":    reflect.TypeFor[uint](),
+	"uint8":   reflect.TypeFor[uint8](),
+	"uint16":  reflect.TypeFor[uint16](),
+	"uint32":  reflect.TypeFor[uint32](),
+	"uint64":  reflect.TypeFor[uint64](),
+	"float32": reflect.TypeFor[float32](),
+	"float64": reflect.TypeFor[float64](),
+	"string":  reflect.TypeFor[string](),
+	"bytes":   reflect.TypeFor[[]byte](),
+}
+
+type typedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func marshalValue[T any](v T) (json.RawMessage, error) {
+	if reflect.TypeFor[T]().Kind() != reflect.Interface {
+		return json.Marshal(v)
+	}
+
+	iv := any(v)
+	if iv == nil {
+		return json.Marshal(typedValue{Type: "null"})
+	}
+	name := ""
+	for n, t := range valueTypes {
+		if reflect.TypeOf(iv) == t {
+			name = n
+			break
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("unsupported value type %T", iv)
+	}
+
+	bz, err := json.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(typedValue{Type: name, Value: bz})
+}
+
+func unmarshalValue[T any](data json.RawMessage, v *T) error {
+	if reflect.TypeFor[T]().Kind() != reflect.Interface {
+		return json.Unmarshal(data, v)
+	}
+
+	var tv typedValue
+	if err := json.Unmarshal(data, &tv); err != nil {
+		return fmt.Errorf("failed to unmarshal typed value: %w", err)
+	}
+	if tv.Type == "null" {
+		var zero T
+		*v = zero
+		return nil
+	}
+	t, ok := valueTypes[tv.Type]
+	if !ok {
+		return fmt.Errorf("unsupported value type %s", tv.Type)
+	}
+
+	rv := reflect.New(t)
+	if err := json.Unmarshal(tv.Value, rv.Interface()); err != nil {
+		return fmt.Errorf("failed to unmarshal %s value: %w", tv.Type, err)
+	}
+	*v = rv.Elem().Interface().(T)
+	return nil
+}
+
+func marshalValues[T any](vs []T) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, 0, len(vs))
+	for _, v := range vs {
+		bz, err := marshalValue(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, bz)
+	}
+	return out, nil
+}
+
+func unmarshalValues[T any](data []json.RawMessage) ([]T, error) {
+	vs := make([]T, len(data))
+	for i, bz := range data {
+		if err := unmarshalValue(bz, &vs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return vs, nil
+}
+
+type typedExpr struct {
+	Kind string          `json:"kind"`
+	Expr json.RawMessage `json:"expr"`
+}
+
+// MarshalExpr marshals an expression along with its kind so that it can be unmarshaled by UnmarshalExpr.
+// Only the any instantiations of the generic expressions are supported, e.g. Range[any].
+func MarshalExpr(e any) ([]byte, error) {
+	var kind string
+	switch e.(type) {
+	case Assigned:
+		kind = "assigned"
+	case Exact[any]:
+		kind = "exact"
+	case NotEqual[any]:
+		kind = "not_equal"
+	case Range[any]:
+		kind = "range"
+	case Set[any]:
+		kind = "set"
+	case Prefix[any]:
+		kind = "prefix"
+	case Like:
+		kind = "like"
+	case Regexp:
+		kind = "regexp"
+	case Not:
+		kind = "not"
+	case Or:
+		kind = "or"
+	case And:
+		kind = "and"
+	default:
+		return nil, fmt.Errorf("unsupported expression type %T", e)
+	}
+
+	bz, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s expression: %w", kind, err)
+	}
+	return json.Marshal(typedExpr{Kind: kind, Expr: bz})
+}
+
+// UnmarshalExpr unmarshals an expression that is marshaled by MarshalExpr.
+func UnmarshalExpr(data []byte) (any, error) {
+	var te typedExpr
+	if err := json.Unmarshal(data, &te); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal expression: %w", err)
+	}
+
+	var (
+		e   any
+		err error
+	)
+	switch te.Kind {
+	case "assigned":
+		e, err = unmarshalAs[Assigned](te.Expr)
+	case "exact":
+		e, err = unmarshalAs[Exact[any]](te.Expr)
+	case "not_equal":
+		e, err = unmarshalAs[NotEqual[any]](te.Expr)
+	case "range":
+		e, err = unmarshalAs[Range[any]](te.Expr)
+	case "set":
+		e, err = unmarshalAs[Set[any]](te.Expr)
+	case "prefix":
+		e, err = unmarshalAs[Prefix[any]](te.Expr)
+	case "like":
+		e, err = unmarshalAs[Like](te.Expr)
+	case "regexp":
+		e, err = unmarshalAs[Regexp](te.Expr)
+	case "not":
+		e, err = unmarshalAs[Not](te.Expr)
+	case "or":
+		e, err = unmarshalAs[Or](te.Expr)
+	case "and":
+		e, err = unmarshalAs[And](te.Expr)
+	default:
+		return nil, fmt.Errorf("unsupported expression kind %q", te.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s expression: %w", te.Kind, err)
+	}
+	return e, nil
+}
+
+func unmarshalAs[E any](data []byte) (E, error) {
+	var e E
+	err := json.Unmarshal(data, &e)
+	return e, err
+}
+
+func marshalExprs(es []any) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, 0, len(es))
+	for _, e := range es {
+		bz, err := MarshalExpr(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, bz)
+	}
+	return out, nil
+}
+
+func unmarshalExprs(data []json.RawMessage) ([]any, error) {
+	es := make([]any, 0, len(data))
+	for _, bz := range data {
+		e, err := UnmarshalExpr(bz)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+type boundJSON struct {
+	Value     json.RawMessage `json:"value"`
+	Exclusive bool            `json:"exclusive,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (b Bound[T]) MarshalJSON() ([]byte, error) {
+	v, err := marshalValue(b.value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(boundJSON{Value: v, Exclusive: b.exclusive})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (b *Bound[T]) UnmarshalJSON(data []byte) error {
+	var bj boundJSON
+	if err := json.Unmarshal(data, &bj); err != nil {
+		return err
+	}
+	b.exclusive = bj.Exclusive
+	return unmarshalValue(bj.Value, &b.value)
+}
+
+type rangeJSON[T any] struct {
+	Low  *Bound[T] `json:"low"`
+	High *Bound[T] `json:"high"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Unbounded ends are marshaled as null.
+func (r Range[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rangeJSON[T]{Low: r.low, High: r.high})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (r *Range[T]) UnmarshalJSON(data []byte) error {
+	var rj rangeJSON[T]
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return err
+	}
+	r.low, r.high = rj.Low, rj.High
+	return nil
+}
+
+type valueJSON struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e Exact[T]) MarshalJSON() ([]byte, error) {
+	v, err := marshalValue(e.value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valueJSON{Value: v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *Exact[T]) UnmarshalJSON(data []byte) error {
+	var vj valueJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return err
+	}
+	return unmarshalValue(vj.Value, &e.value)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n NotEqual[T]) MarshalJSON() ([]byte, error) {
+	v, err := marshalValue(n.value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valueJSON{Value: v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *NotEqual[T]) UnmarshalJSON(data []byte) error {
+	var vj valueJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return err
+	}
+	return unmarshalValue(vj.Value, &n.value)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p Prefix[T]) MarshalJSON() ([]byte, error) {
+	v, err := marshalValue(p.value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valueJSON{Value: v})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *Prefix[T]) UnmarshalJSON(data []byte) error {
+	var vj valueJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return err
+	}
+	return unmarshalValue(vj.Value, &p.value)
+}
+
+type valuesJSON struct {
+	Values []json.RawMessage `json:"values"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s Set[T]) MarshalJSON() ([]byte, error) {
+	vs, err := marshalValues(s.values)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valuesJSON{Values: vs})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var vj valuesJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return err
+	}
+
+	var err error
+	s.values, err = unmarshalValues[T](vj.Values)
+	return err
+}
+
+type patternJSON struct {
+	Pattern string `json:"pattern"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (l Like) MarshalJSON() ([]byte, error) {
+	return json.Marshal(patternJSON{Pattern: l.pattern})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (l *Like) UnmarshalJSON(data []byte) error {
+	var pj patternJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	l.pattern = pj.Pattern
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (r Regexp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(patternJSON{Pattern: r.pattern})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (r *Regexp) UnmarshalJSON(data []byte) error {
+	var pj patternJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	r.pattern = pj.Pattern
+	return nil
+}
+
+type assignedJSON struct {
+	Name       string          `json:"name"`
+	Expression json.RawMessage `json:"expression"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n Assigned) MarshalJSON() ([]byte, error) {
+	e, err := MarshalExpr(n.expression)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(assignedJSON{Name: n.name, Expression: e})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *Assigned) UnmarshalJSON(data []byte) error {
+	var aj assignedJSON
+	if err := json.Unmarshal(data, &aj); err != nil {
+		return err
+	}
+
+	e, err := UnmarshalExpr(aj.Expression)
+	if err != nil {
+		return err
+	}
+	n.name, n.expression = aj.Name, e
+	return nil
+}
+
+type exprJSON struct {
+	Expression json.RawMessage `json:"expression"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n Not) MarshalJSON() ([]byte, error) {
+	e, err := MarshalExpr(n.expression)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exprJSON{Expression: e})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *Not) UnmarshalJSON(data []byte) error {
+	var ej exprJSON
+	if err := json.Unmarshal(data, &ej); err != nil {
+		return err
+	}
+
+	var err error
+	n.expression, err = UnmarshalExpr(ej.Expression)
+	return err
+}
+
+type exprsJSON struct {
+	Expressions []json.RawMessage `json:"expressions"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o Or) MarshalJSON() ([]byte, error) {
+	es, err := marshalExprs(o.expressions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exprsJSON{Expressions: es})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (o *Or) UnmarshalJSON(data []byte) error {
+	var ej exprsJSON
+	if err := json.Unmarshal(data, &ej); err != nil {
+		return err
+	}
+
+	var err error
+	o.expressions, err = unmarshalExprs(ej.Expressions)
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (a And) MarshalJSON() ([]byte, error) {
+	es, err := marshalExprs(a.expressions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exprsJSON{Expressions: es})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (a *And) UnmarshalJSON(data []byte) error {
+	var ej exprsJSON
+	if err := json.Unmarshal(data, &ej); err != nil {
+		return err
+	}
+
+	var err error
+	a.expressions, err = unmarshalExprs(ej.Expressions)
+	return err
+}
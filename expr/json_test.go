@@ -0,0 +1,71 @@
+package expr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	t.Run("Range", func(t *testing.T) {
+		r := expr.NewRange(expr.NewBound(1, true), nil)
+		bz, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"low": {"value": 1, "exclusive": true}, "high": null}`, string(bz))
+
+		var got expr.Range[int]
+		require.NoError(t, json.Unmarshal(bz, &got))
+		require.Equal(t, r, got)
+	})
+
+	t.Run("Typed values", func(t *testing.T) {
+		r := expr.NewRange(expr.NewBound[any](int64(1), false), expr.NewBound[any]([]byte{0x01}, false))
+		bz, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"low": {"value": {"type": "int64", "value": 1}},
+			"high": {"value": {"type": "bytes", "value": "AQ=="}}
+		}`, string(bz))
+
+		var got expr.Range[any]
+		require.NoError(t, json.Unmarshal(bz, &got))
+		require.Equal(t, r, got)
+	})
+
+	t.Run("Assigned", func(t *testing.T) {
+		tests := []any{
+			expr.NewExact[any]("foo"),
+			expr.NewNotEqual[any](uint8(3)),
+			expr.NewRange[any](nil, expr.NewBound[any](2.5, true)),
+			expr.NewSet[any](1, int32(2), nil),
+			expr.NewPrefix[any]("ab"),
+			expr.NewLike("a%"),
+			expr.NewRegexp("^a"),
+			expr.NewNot(expr.NewExact[any](true)),
+			expr.NewOr(expr.NewExact[any](1), expr.NewAnd(expr.NewExact[any](2), expr.NewNotEqual[any](3))),
+		}
+
+		for _, e := range tests {
+			a := expr.NewAssigned("A", e)
+			bz, err := json.Marshal(a)
+			require.NoError(t, err)
+
+			var got expr.Assigned
+			require.NoError(t, json.Unmarshal(bz, &got), string(bz))
+			require.Equal(t, a, got, string(bz))
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := json.Marshal(expr.NewExact[any](struct{}{}))
+		require.Error(t, err)
+
+		_, err = json.Marshal(expr.NewAssigned("A", expr.NewExact(1)))
+		require.Error(t, err)
+
+		var got expr.Assigned
+		require.Error(t, json.Unmarshal([]byte(`{"name": "A", "expression": {"kind": "foo"}}`), &got))
+	})
+}
@@ -0,0 +1,53 @@
+package expr
+
+// Not represents the negation of an expression.
+type Not struct {
+	expression any
+}
+
+// NewNot creates a new negation expression.
+func NewNot(expr any) Not {
+	return Not{expression: expr}
+}
+
+// Expression returns the negated expression.
+func (n Not) Expression() any { return n.expression }
+
+// NotEqual represents an inequality expression.
+type NotEqual[T any] struct {
+	value T
+}
+
+// NewNotEqual creates a new inequality expression.
+func NewNotEqual[T any](value T) NotEqual[T] {
+	return NotEqual[T]{value: value}
+}
+
+// Value returns the value that must not be equal.
+func (n NotEqual[T]) Value() T { return n.value }
+
+// Or represents a disjunction of expressions that at least one of them must be satisfied.
+type Or struct {
+	expressions []any
+}
+
+// NewOr creates a new disjunction expression.
+func NewOr(exprs ...any) Or {
+	return Or{expressions: exprs}
+}
+
+// Expressions returns the expressions of the disjunction.
+func (o Or) Expressions() []any { return o.expressions }
+
+// And represents a conjunction of expressions that all of them must be satisfied.
+type And struct {
+	expressions []any
+}
+
+// NewAnd creates a new conjunction expression.
+func NewAnd(exprs ...any) And {
+	return And{expressions: exprs}
+}
+
+// Expressions returns the expressions of the conjunction.
+func (a And) Expressions() []any { return a.expressions }
@@ -0,0 +1,181 @@
+package expr
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseRange parses a range in the interval notation, e.g. "[1, 5)", "(-∞, 0x0a]" or `["a", ∞)`.
+// Byte slices are written in hex with 0x prefix and strings are quoted. Both of "∞" and "inf" denote an
+// unbounded end. Values of interface types are inferred from their notation.
+func ParseRange[T any](s string) (Range[T], error) {
+	return ParseRangeFunc(s, parseValue[T])
+}
+
+// ParseRangeFunc parses a range in the interval notation using the given function to parse the values.
+func ParseRangeFunc[T any](s string, parse func(string) (T, error)) (Range[T], error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return Range[T]{}, fmt.Errorf("invalid range %q", s)
+	}
+
+	var lowExc, highExc bool
+	switch s[0] {
+	case '[':
+	case '(':
+		lowExc = true
+	default:
+		return Range[T]{}, fmt.Errorf("invalid range %q: must start with [ or (", s)
+	}
+	switch s[len(s)-1] {
+	case ']':
+	case ')':
+		highExc = true
+	default:
+		return Range[T]{}, fmt.Errorf("invalid range %q: must end with ] or )", s)
+	}
+
+	lowStr, highStr, err := splitRange(s[1 : len(s)-1])
+	if err != nil {
+		return Range[T]{}, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+
+	var low, high *Bound[T]
+	if !isInfinity(lowStr, true) {
+		v, err := parse(lowStr)
+		if err != nil {
+			return Range[T]{}, fmt.Errorf("failed to parse low value: %w", err)
+		}
+		low = NewBound(v, lowExc)
+	}
+	if !isInfinity(highStr, false) {
+		v, err := parse(highStr)
+		if err != nil {
+			return Range[T]{}, fmt.Errorf("failed to parse high value: %w", err)
+		}
+		high = NewBound(v, highExc)
+	}
+
+	return NewRange(low, high), nil
+}
+
+// splitRange splits the inner part of a range at the comma that is not quoted.
+func splitRange(s string) (string, string, error) {
+	var (
+		quoted  bool
+		escaped bool
+	)
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), nil
+		}
+	}
+	return "", "", errors.New("missing comma")
+}
+
+func isInfinity(s string, low bool) bool {
+	if low {
+		return s == "-∞" || s == "-inf"
+	}
+	return s == "∞" || s == "+∞" || s == "inf" || s == "+inf"
+}
+
+func parseValue[T any](s string) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() == reflect.Interface {
+		iv, err := inferValue(s)
+		if err != nil {
+			return v, err
+		}
+		return iv.(T), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return v, err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return v, err
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return v, err
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		str, err := strconv.Unquote(s)
+		if err != nil {
+			return v, fmt.Errorf("invalid quoted string %s: %w", s, err)
+		}
+		rv.SetString(str)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return v, fmt.Errorf("unsupported type %s", rv.Type())
+		}
+		bz, err := parseHex(s)
+		if err != nil {
+			return v, err
+		}
+		rv.SetBytes(bz)
+	default:
+		return v, fmt.Errorf("unsupported type %s", rv.Type())
+	}
+
+	return v, nil
+}
+
+// inferValue parses a value whose type is unknown: hex bytes, quoted strings, booleans, integers and floats.
+func inferValue(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		return parseHex(s)
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case s == "true" || s == "false":
+		return s == "true", nil
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return int(i), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unable to infer the type of %s", s)
+}
+
+func parseHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("invalid hex %s: missing 0x prefix", s)
+	}
+	bz, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %s: %w", s, err)
+	}
+	return bz, nil
+}
@@ -0,0 +1,74 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	t.Run("Bytes", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected expr.Range[[]byte]
+		}{
+			{
+				input:    "[0x01, 0x02]",
+				expected: expr.NewRange(expr.NewBound([]byte{0x01}, false), expr.NewBound([]byte{0x02}, false)),
+			},
+			{
+				input:    "(0x01, 0x02)",
+				expected: expr.NewRange(expr.NewBound([]byte{0x01}, true), expr.NewBound([]byte{0x02}, true)),
+			},
+			{
+				input:    "(-∞, ∞)",
+				expected: expr.NewRange[[]byte](nil, nil),
+			},
+			{
+				input:    " [0x, inf) ",
+				expected: expr.NewRange(expr.NewBound([]byte{}, false), nil),
+			},
+		}
+
+		for _, test := range tests {
+			r, err := expr.ParseRange[[]byte](test.input)
+			require.NoError(t, err, test.input)
+			require.Equal(t, test.expected, r, test.input)
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		for _, r := range []expr.Range[int]{
+			expr.NewRange(expr.NewBound(-5, true), expr.NewBound(5, false)),
+			expr.NewRange(nil, expr.NewBound(5, true)),
+			expr.NewRange(expr.NewBound(5, false), nil),
+		} {
+			parsed, err := expr.ParseRange[int](r.String())
+			require.NoError(t, err, r.String())
+			require.Equal(t, r, parsed)
+		}
+
+		s := expr.NewRange(expr.NewBound(`a, "b"`, false), expr.NewBound("c", true))
+		parsed, err := expr.ParseRange[string](s.String())
+		require.NoError(t, err, s.String())
+		require.Equal(t, s, parsed)
+	})
+
+	t.Run("Inferred", func(t *testing.T) {
+		r, err := expr.ParseRange[any](`[1, 2.5)`)
+		require.NoError(t, err)
+		require.Equal(t, expr.NewRange(expr.NewBound[any](1, false), expr.NewBound[any](2.5, true)), r)
+
+		r, err = expr.ParseRange[any](`["a", 0x0a]`)
+		require.NoError(t, err)
+		require.Equal(t, expr.NewRange(expr.NewBound[any]("a", false), expr.NewBound[any]([]byte{0x0a}, false)), r)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, input := range []string{"", "1, 2", "[1 2]", "[1, 2", "[a, 2]", "[1, 2, 3]"} {
+			_, err := expr.ParseRange[int](input)
+			require.Error(t, err, input)
+		}
+	})
+}
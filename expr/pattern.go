@@ -0,0 +1,41 @@
+package expr
+
+// Like represents a LIKE pattern matching expression.
+// Both % and * match any sequence of characters and both _ and ? match a single character.
+type Like struct {
+	pattern string
+}
+
+// NewLike creates a new like expression.
+func NewLike(pattern string) Like {
+	return Like{pattern: pattern}
+}
+
+// Pattern returns the pattern of the like expression.
+func (l Like) Pattern() string { return l.pattern }
+
+// Regexp represents a regular expression matching expression.
+type Regexp struct {
+	pattern string
+}
+
+// NewRegexp creates a new regular expression matching expression.
+func NewRegexp(pattern string) Regexp {
+	return Regexp{pattern: pattern}
+}
+
+// Pattern returns the pattern of the regular expression.
+func (r Regexp) Pattern() string { return r.pattern }
+
+// Prefix represents a starts-with expression.
+type Prefix[T any] struct {
+	value T
+}
+
+// NewPrefix creates a new prefix expression.
+func NewPrefix[T any](value T) Prefix[T] {
+	return Prefix[T]{value: value}
+}
+
+// Value returns the prefix that the value must start with.
+func (p Prefix[T]) Value() T { return p.value }
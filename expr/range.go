@@ -3,6 +3,7 @@ package expr
 import (
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -23,46 +24,46 @@ func (r Range[T]) Low() *Bound[T] { return r.low }
 // High returns the high bound of the range.
 func (r Range[T]) High() *Bound[T] { return r.high }
 
-// String returns the string representation of the range.
+// String returns the string representation of the range in the interval notation which can be parsed by ParseRange.
+// The unbounded low bound of byte slice ranges is written as [0x00.
 func (r Range[T]) String() string {
 	var sb strings.Builder
-	if !r.Low().IsEmpty() && r.Low().Exclusive() {
-		sb.WriteString("(")
+	if r.Low().IsEmpty() {
+		if _, ok := any(*new(T)).([]byte); ok {
+			sb.WriteString("[0x00")
+		} else {
+			sb.WriteString("(-∞")
+		}
 	} else {
-		sb.WriteString("[")
+		if r.Low().Exclusive() {
+			sb.WriteString("(")
+		} else {
+			sb.WriteString("[")
+		}
+		sb.WriteString(formatValue(r.Low().Value()))
 	}
-	sb.WriteString(r.encodeBound(r.Low(), true))
 	sb.WriteString(", ")
-	sb.WriteString(r.encodeBound(r.High(), false))
-	if r.Low().IsEmpty() || r.High().Exclusive() {
-		sb.WriteString(")")
+	if r.High().IsEmpty() {
+		sb.WriteString("∞)")
 	} else {
-		sb.WriteString("]")
+		sb.WriteString(formatValue(r.High().Value()))
+		if r.High().Exclusive() {
+			sb.WriteString(")")
+		} else {
+			sb.WriteString("]")
+		}
 	}
 	return sb.String()
 }
 
-func (r Range[T]) encodeBound(b *Bound[T], low bool) string {
-	var t T
-	switch any(t).(type) {
+func formatValue(v any) string {
+	switch v := v.(type) {
 	case []byte:
-		if b.IsEmpty() {
-			if low {
-				return "0x00"
-			} else {
-				return "∞"
-			}
-		}
-		return "0x" + hex.EncodeToString(any(b.Value()).([]byte))
+		return "0x" + hex.EncodeToString(v)
+	case string:
+		return strconv.Quote(v)
 	default:
-		if b.IsEmpty() {
-			if low {
-				return "-∞"
-			} else {
-				return "∞"
-			}
-		}
-		return fmt.Sprint(b.Value())
+		return fmt.Sprint(v)
 	}
 }
 
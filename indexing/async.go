@@ -0,0 +1,310 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	msgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// DefaultWorkerInterval is the default interval that a Worker polls the change log.
+	DefaultWorkerInterval = 100 * time.Millisecond
+	// DefaultWorkerBatchSize is the default number of changes that a Worker applies in a single transaction.
+	DefaultWorkerBatchSize = 1000
+)
+
+// change is a change log entry of a key that is not applied to the index yet.
+// Olds are the index keys of the key that are currently in the index and News are the ones that should replace them.
+// Multiple writes to the same key before the change is applied are merged to a single entry.
+// ExpiresAt is the expiry of the News and Refresh is set if any of the merged writes must rewrite all of the refs.
+type change struct {
+	Olds      [][]byte                `msgpack:"o"`
+	News      []badgerutils.RawKVPair `msgpack:"n"`
+	ExpiresAt uint64                  `msgpack:"e,omitempty"`
+	Refresh   bool                    `msgpack:"r,omitempty"`
+}
+
+func (e *ExtensionInstance[T]) enqueue(key []byte, olds, news []badgerutils.RawKVPair, expiresAt uint64, refresh bool) error {
+	c, err := e.pendingChange(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		c = &change{Olds: make([][]byte, 0, len(olds))}
+		for _, kv := range olds {
+			c.Olds = append(c.Olds, kv.Key)
+		}
+	} else if err != nil {
+		return err
+	}
+	c.News = news
+	c.ExpiresAt = expiresAt
+	c.Refresh = c.Refresh || refresh
+
+	bz, err := msgpack.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change: %w", err)
+	}
+	return e.changesStore.Set(key, bz)
+}
+
+func (e *ExtensionInstance[T]) pendingChange(key []byte) (*change, error) {
+	item, err := e.changesStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var c change
+	err = item.Value(func(val []byte) error {
+		return msgpack.Unmarshal(val, &c)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal change: %w", err)
+	}
+	return &c, nil
+}
+
+// Pending reports whether the change log of an async extension has changes that are not applied to the index yet
+// as seen by the transaction of the instance. Until they are applied, lookups miss the records written since
+// and may return the keys of the deleted ones.
+func (e *ExtensionInstance[T]) Pending() (bool, error) {
+	if !e.ext.async {
+		return false, nil
+	}
+
+	iter := e.changesStore.NewIterator(badger.IteratorOptions{PrefetchValues: false})
+	defer iter.Close()
+
+	iter.Rewind()
+	return iter.Valid(), nil
+}
+
+// applyPending applies at most n changes of the change log to the index and returns the number of applied changes.
+func (e *ExtensionInstance[T]) applyPending(n int) (int, error) {
+	iter := e.changesStore.NewIterator(badger.IteratorOptions{PrefetchValues: true})
+	defer iter.Close()
+
+	prefixLen := len(e.ext.changesStore.Prefix())
+	var applied int
+	for iter.Rewind(); iter.Valid() && applied < n; iter.Next() {
+		item := iter.Item()
+		key := item.KeyCopy(nil)[prefixLen:]
+
+		var c change
+		err := item.Value(func(val []byte) error {
+			return msgpack.Unmarshal(val, &c)
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to unmarshal change: %w", err)
+		}
+
+		olds := make([]badgerutils.RawKVPair, 0, len(c.Olds))
+		for _, k := range c.Olds {
+			olds = append(olds, badgerutils.NewRawKVPair(k, nil))
+		}
+		if err := e.apply(key, olds, c.News, c.ExpiresAt, c.Refresh); err != nil {
+			return applied, err
+		}
+		if err := e.changesStore.Delete(key); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+
+	return applied, nil
+}
+
+// Worker applies the change log of an async index extension to the index in the background.
+type Worker struct {
+	db        *badger.DB
+	apply     func(txn *badger.Txn, n int) (int, error)
+	interval  time.Duration
+	batchSize int
+
+	mu      sync.Mutex
+	hwm     uint64
+	err     error
+	advance chan struct{}
+
+	kick    chan struct{}
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// WithWorkerInterval sets the interval that the worker polls the change log.
+func WithWorkerInterval(d time.Duration) func(*Worker) {
+	if d <= 0 {
+		panic("interval must be positive")
+	}
+
+	return func(w *Worker) {
+		w.interval = d
+	}
+}
+
+// WithWorkerBatchSize sets the maximum number of changes that the worker applies in a single transaction.
+func WithWorkerBatchSize(n int) func(*Worker) {
+	if n <= 0 {
+		panic("batch size must be positive")
+	}
+
+	return func(w *Worker) {
+		w.batchSize = n
+	}
+}
+
+// StartWorker starts a worker that applies the change log of the extension to the index.
+// It panics if the extension is not async or is not registered in a store yet.
+// The worker doesn't support databases opened in managed mode (see badger.OpenManaged) as it applies
+// the changes in badger.DB.Update which panics on them.
+func (e *Extension[T]) StartWorker(db *badger.DB, opts ...func(*Worker)) *Worker {
+	if !e.async {
+		panic("extension is not async")
+	}
+	if e.changesStore == nil {
+		panic("extension is not registered")
+	}
+
+	w := &Worker{
+		db: db,
+		apply: func(txn *badger.Txn, n int) (int, error) {
+			return e.Instantiate(txn).(*ExtensionInstance[T]).applyPending(n)
+		},
+		interval:  DefaultWorkerInterval,
+		batchSize: DefaultWorkerBatchSize,
+		advance:   make(chan struct{}),
+		kick:      make(chan struct{}, 1),
+		closing:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	go w.run()
+	return w
+}
+
+func (w *Worker) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.drain()
+
+		select {
+		case <-w.closing:
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+	}
+}
+
+// drain applies the change log in batches until it's empty.
+func (w *Worker) drain() {
+	for {
+		var (
+			n      int
+			readTs uint64
+		)
+		err := w.db.Update(func(txn *badger.Txn) error {
+			readTs = txn.ReadTs()
+
+			var err error
+			n, err = w.apply(txn, w.batchSize)
+			return err
+		})
+		if errors.Is(err, badger.ErrConflict) {
+			// Some of the changes are modified concurrently, they will be retried on the next round.
+			return
+		}
+		if err != nil {
+			w.mu.Lock()
+			w.err = err
+			w.mu.Unlock()
+			return
+		}
+
+		if n < w.batchSize {
+			// All the changes committed up to readTs are applied.
+			w.setHighWaterMark(readTs)
+			return
+		}
+	}
+}
+
+func (w *Worker) setHighWaterMark(ts uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.err = nil
+	if ts > w.hwm {
+		w.hwm = ts
+		close(w.advance)
+		w.advance = make(chan struct{})
+	}
+}
+
+// HighWaterMark returns the timestamp up to which all the committed changes are applied to the index.
+func (w *Worker) HighWaterMark() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.hwm
+}
+
+// Err returns the error of the last round of the worker if any.
+func (w *Worker) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+// WaitFor blocks until all the changes committed up to ts are applied to the index or ctx is done.
+// For read-your-writes, ts can be obtained from db.MaxVersion() after the write is committed and
+// the read transaction must be started after WaitFor returns.
+func (w *Worker) WaitFor(ctx context.Context, ts uint64) error {
+	for {
+		w.mu.Lock()
+		hwm, advance := w.hwm, w.advance
+		w.mu.Unlock()
+		if hwm >= ts {
+			return nil
+		}
+
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.done:
+			return errors.New("worker is closed")
+		case <-advance:
+		}
+	}
+}
+
+// Sync blocks until all the changes committed so far are applied to the index or ctx is done.
+func (w *Worker) Sync(ctx context.Context) error {
+	return w.WaitFor(ctx, w.db.MaxVersion())
+}
+
+// Close stops the worker and waits for it to exit.
+func (w *Worker) Close() {
+	select {
+	case <-w.closing:
+	default:
+		close(w.closing)
+	}
+	<-w.done
+}
@@ -0,0 +1,69 @@
+package indexing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+	"github.com/ehsanranjbar/badgerutils/iters"
+	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ext := indexing.NewExtension[TestStruct](tagsIndexer{}, indexing.WithAsync[TestStruct]())
+	store := extstore.New[TestStruct](nil).WithExtension("tags", ext)
+
+	set := func(key byte, v *TestStruct) {
+		require.NoError(t, db.Update(func(txn *badger.Txn) error {
+			return store.Instantiate(txn).Set([]byte{key}, v)
+		}))
+	}
+	lookup := func(tag string) [][]byte {
+		var keys [][]byte
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			extIns := store.Instantiate(txn).GetExtension("tags").(*indexing.ExtensionInstance[TestStruct])
+			it, err := extIns.Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("B", expr.NewExact[any](tag)))
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+
+			keys, err = iters.Collect(it)
+			return err
+		}))
+		return keys
+	}
+
+	set(1, &TestStruct{B: "a,b"})
+	set(2, &TestStruct{B: "b,c"})
+	// Writes to the same key before the worker runs are merged.
+	set(1, &TestStruct{B: "a,c"})
+	require.Empty(t, lookup("a"))
+
+	w := ext.(*indexing.Extension[TestStruct]).StartWorker(db, indexing.WithWorkerInterval(time.Hour))
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, w.Sync(ctx))
+	require.NoError(t, w.Err())
+	require.Equal(t, [][]byte{{1}}, lookup("a"))
+	require.Equal(t, [][]byte{{2}}, lookup("b"))
+	require.Equal(t, [][]byte{{1}, {2}}, lookup("c"))
+
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return store.Instantiate(txn).Delete([]byte{2})
+	}))
+	require.NoError(t, w.Sync(ctx))
+	require.Empty(t, lookup("b"))
+	require.Equal(t, [][]byte{{1}}, lookup("c"))
+}
@@ -0,0 +1,49 @@
+package indexing
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ehsanranjbar/badgerutils/schema"
+)
+
+// ChangeDetector is an optional interface for indexers that can tell whether the indexed parts of a value
+// have changed without computing the index keys, so that updates which don't touch them can be skipped.
+type ChangeDetector[T any] interface {
+	Changed(old, new *T) (bool, error)
+}
+
+// PathsChanged reports whether any of the given paths has a different value in old and new.
+func PathsChanged[T any](extractor schema.PathExtractor[T], old, new *T, paths ...string) (bool, error) {
+	if old == nil || new == nil {
+		return old != new, nil
+	}
+
+	for _, path := range paths {
+		o, err := extractor.ExtractPath(*old, path)
+		if err != nil {
+			return false, fmt.Errorf("failed to extract path %s: %w", path, err)
+		}
+		n, err := extractor.ExtractPath(*new, path)
+		if err != nil {
+			return false, fmt.Errorf("failed to extract path %s: %w", path, err)
+		}
+
+		if !reflect.DeepEqual(unwrapValue(o), unwrapValue(n)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func unwrapValue(v any) any {
+	rv, ok := v.(reflect.Value)
+	if !ok {
+		return v
+	}
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil
+	}
+	return rv.Interface()
+}
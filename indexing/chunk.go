@@ -19,6 +19,31 @@ func NewChunk(low, high *expr.Bound[[]byte]) Chunk {
 	return expr.NewRange(low, high)
 }
 
+// ChunkComparator is the comparator of the chunk bounds, i.e. the lexicographical order of the keys.
+var ChunkComparator expr.Comparator[[]byte] = bytes.Compare
+
+// NormalizeChunks drops the empty chunks, sorts the rest by their low bounds and merges the overlapping and
+// adjacent ones so that the result is a list of disjoint chunks in ascending order.
+func NormalizeChunks(chunks []Chunk) []Chunk {
+	return expr.NormalizeRanges(ChunkComparator, chunks...)
+}
+
+// IntersectChunks returns the normalized chunks that are covered by both of the given chunk sets.
+func IntersectChunks(a, b []Chunk) []Chunk {
+	return expr.IntersectRanges(ChunkComparator, a, b)
+}
+
+// ComplementChunks returns the normalized chunks that cover every key that is not covered by the given chunks.
+func ComplementChunks(chunks []Chunk) []Chunk {
+	cs := expr.ComplementRanges(ChunkComparator, chunks...)
+	if len(cs) > 0 && cs[0].Low().IsEmpty() && !cs[0].High().IsEmpty() &&
+		len(cs[0].High().Value()) == 0 && cs[0].High().Exclusive() {
+		// Nothing is lower than the empty key.
+		cs = cs[1:]
+	}
+	return cs
+}
+
 // LookupChunks returns an iterator that iterates over the keys in the given chunk iterator.
 func LookupChunks(
 	store *refstore.Instance,
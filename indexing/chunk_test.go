@@ -216,3 +216,133 @@ func isWithin(r expr.Range[uint64], value uint64) bool {
 
 	return true
 }
+
+func TestNormalizeChunks(t *testing.T) {
+	chunk := func(low, high string, lowExc, highExc bool) indexing.Chunk {
+		var l, h *expr.Bound[[]byte]
+		if low != "" {
+			l = expr.NewBound([]byte(low), lowExc)
+		}
+		if high != "" {
+			h = expr.NewBound([]byte(high), highExc)
+		}
+		return indexing.NewChunk(l, h)
+	}
+
+	tests := []struct {
+		name     string
+		chunks   []indexing.Chunk
+		expected []indexing.Chunk
+	}{
+		{
+			name:     "Empty",
+			chunks:   nil,
+			expected: []indexing.Chunk{},
+		},
+		{
+			name:     "Duplicates",
+			chunks:   []indexing.Chunk{chunk("a", "a", false, false), chunk("a", "a", false, false)},
+			expected: []indexing.Chunk{chunk("a", "a", false, false)},
+		},
+		{
+			name:     "Sorting",
+			chunks:   []indexing.Chunk{chunk("c", "d", false, false), chunk("a", "b", false, false)},
+			expected: []indexing.Chunk{chunk("a", "b", false, false), chunk("c", "d", false, false)},
+		},
+		{
+			name:     "Overlapping",
+			chunks:   []indexing.Chunk{chunk("a", "c", false, false), chunk("b", "d", false, true)},
+			expected: []indexing.Chunk{chunk("a", "d", false, true)},
+		},
+		{
+			name:     "Contained",
+			chunks:   []indexing.Chunk{chunk("a", "d", false, false), chunk("b", "c", false, false)},
+			expected: []indexing.Chunk{chunk("a", "d", false, false)},
+		},
+		{
+			name:     "Adjacent",
+			chunks:   []indexing.Chunk{chunk("a", "b", false, true), chunk("b", "c", false, false)},
+			expected: []indexing.Chunk{chunk("a", "c", false, false)},
+		},
+		{
+			name:     "Both exclusive",
+			chunks:   []indexing.Chunk{chunk("a", "b", false, true), chunk("b", "c", true, false)},
+			expected: []indexing.Chunk{chunk("a", "b", false, true), chunk("b", "c", true, false)},
+		},
+		{
+			name:     "Unbounded",
+			chunks:   []indexing.Chunk{chunk("b", "c", false, false), chunk("", "a", false, false), chunk("b", "", false, false)},
+			expected: []indexing.Chunk{chunk("", "a", false, false), chunk("b", "", false, false)},
+		},
+		{
+			name:     "Empty chunks",
+			chunks:   []indexing.Chunk{chunk("b", "a", false, false), chunk("a", "a", true, false)},
+			expected: []indexing.Chunk{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, indexing.NormalizeChunks(test.chunks))
+		})
+	}
+}
+
+func TestIntersectChunks(t *testing.T) {
+	a := []indexing.Chunk{
+		indexing.NewChunk(expr.NewBound([]byte("a"), false), expr.NewBound([]byte("c"), false)),
+		indexing.NewChunk(expr.NewBound([]byte("e"), true), nil),
+	}
+	b := []indexing.Chunk{
+		indexing.NewChunk(expr.NewBound([]byte("b"), true), expr.NewBound([]byte("f"), false)),
+	}
+
+	require.Equal(t, []indexing.Chunk{
+		indexing.NewChunk(expr.NewBound([]byte("b"), true), expr.NewBound([]byte("c"), false)),
+		indexing.NewChunk(expr.NewBound([]byte("e"), true), expr.NewBound([]byte("f"), false)),
+	}, indexing.IntersectChunks(a, b))
+	require.Empty(t, indexing.IntersectChunks(a, nil))
+}
+
+func TestComplementChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []indexing.Chunk
+		expected []indexing.Chunk
+	}{
+		{
+			name:     "Empty",
+			chunks:   nil,
+			expected: []indexing.Chunk{indexing.NewChunk(nil, nil)},
+		},
+		{
+			name:     "Everything",
+			chunks:   []indexing.Chunk{indexing.NewChunk(nil, nil)},
+			expected: []indexing.Chunk{},
+		},
+		{
+			name:   "Point",
+			chunks: []indexing.Chunk{indexing.NewChunk(expr.NewBound([]byte("b"), false), expr.NewBound([]byte("b"), false))},
+			expected: []indexing.Chunk{
+				indexing.NewChunk(nil, expr.NewBound([]byte("b"), true)),
+				indexing.NewChunk(expr.NewBound([]byte("b"), true), nil),
+			},
+		},
+		{
+			name: "Gaps",
+			chunks: []indexing.Chunk{
+				indexing.NewChunk(expr.NewBound([]byte{}, false), expr.NewBound([]byte("b"), true)),
+				indexing.NewChunk(expr.NewBound([]byte("c"), true), nil),
+			},
+			expected: []indexing.Chunk{
+				indexing.NewChunk(expr.NewBound([]byte("b"), false), expr.NewBound([]byte("c"), false)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, indexing.ComplementChunks(test.chunks))
+		})
+	}
+}
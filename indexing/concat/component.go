@@ -21,6 +21,8 @@ type Component struct {
 	size       int
 	descending bool
 	convertTo  reflect.Type
+	varint     bool
+	terminated bool
 }
 
 // NewComponent creates a new component with the given path.
@@ -60,3 +62,34 @@ func (comp Component) AsInt64() Component {
 	comp.convertTo = int64Type
 	return comp
 }
+
+// Varint makes the component encode integers using the variable-length lex.EncodeVarint encoding
+// instead of padding them to the size of the component, which shrinks the keys of small values.
+// The values of the component must be integers and prefix queries are not supported.
+func (comp Component) Varint() Component {
+	comp.varint = true
+	return comp
+}
+
+// Terminated makes a descending component of strings or byte slices to be encoded by lex.EncodeTerminatedDesc
+// instead of being padded and inverted, so that the values are not padded to the size of the component and
+// a value sorts after the longer ones that it prefixes. The values are not truncated either, so the size of
+// the component is ignored. It changes the keys of the index, so existing indexes must be rebuilt.
+func (comp Component) Terminated() Component {
+	comp.terminated = true
+	return comp
+}
+
+// boundSize returns the size of the encoded bounds of unbounded ranges excluding the type prefix.
+// Varints are self-delimiting and their headers are always in (0x00, 0xff) so a single byte suffices.
+// Terminated values start with at most 0xff 0xfe so two bytes suffice.
+func (comp Component) boundSize() int {
+	switch {
+	case comp.varint:
+		return 1
+	case comp.terminated:
+		return 2
+	default:
+		return comp.size
+	}
+}
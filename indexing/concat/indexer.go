@@ -2,9 +2,11 @@ package concat
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"math"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/ehsanranjbar/badgerutils"
@@ -12,6 +14,7 @@ import (
 	"github.com/ehsanranjbar/badgerutils/codec/be"
 	"github.com/ehsanranjbar/badgerutils/codec/lex"
 	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/indexing"
 	"github.com/ehsanranjbar/badgerutils/iters"
 	"github.com/ehsanranjbar/badgerutils/schema"
 )
@@ -30,25 +33,43 @@ func New[T any](
 	encoder codec.Encoder[any],
 	comps ...Component,
 ) (*Indexer[T], error) {
-	return &Indexer[T]{
+	si := &Indexer[T]{
 		encoder:    encoder,
 		extractor:  extractor,
-		components: comps,
+		components: slices.Clone(comps),
 		queries:    calculateQueries(comps),
-	}, nil
+	}
+	for _, comp := range si.components {
+		if !comp.terminated {
+			continue
+		}
+		if !comp.descending || comp.varint || comp.convertTo != nil {
+			return nil, fmt.Errorf("terminated component %s must be a descending string or byte slice", comp.path)
+		}
+		if t, err := si.componentType(comp); err == nil {
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			if !isRawBytes(t) {
+				return nil, fmt.Errorf("terminated component %s must be a descending string or byte slice", comp.path)
+			}
+		}
+	}
+
+	return si, nil
 }
 
 func calculateQueries(comps []Component) []string {
 	lookups := make([]string, 0, len(comps))
 
-	lookups = append(lookups, fmt.Sprintf("queryable(%s, '=,>,>=,<,<=')", comps[0].path))
+	lookups = append(lookups, fmt.Sprintf("queryable(%s, '=,!=,>,>=,<,<=,prefix')", comps[0].path))
 
 	for i, comp := range comps[1:] {
 		parts := make([]string, 0, i+1)
 		for _, c := range comps[:i+1] {
 			parts = append(parts, fmt.Sprintf("queryable(%s, '=')", c.path))
 		}
-		parts = append(parts, fmt.Sprintf("queryable(%s, '=,>,>=,<,<=')", comp.path))
+		parts = append(parts, fmt.Sprintf("queryable(%s, '=,!=,>,>=,<,<=,prefix')", comp.path))
 
 		lookups = append(lookups, strings.Join(parts, " and "))
 	}
@@ -133,19 +154,42 @@ func (si *Indexer[T]) encodeArrayRV(comp Component, rv reflect.Value) ([][]byte,
 }
 
 func (si *Indexer[T]) encodeSingleRV(comp Component, rv reflect.Value) ([]byte, error) {
+	bz, _, err := si.encodeValue(comp, rv)
+	return bz, err
+}
+
+// encodeValue encodes the value of a component and reports whether the encoding is lossless.
+func (si *Indexer[T]) encodeValue(comp Component, rv reflect.Value) ([]byte, bool, error) {
 	v := rv.Interface()
 
+	exact := true
 	if comp.convertTo != nil {
 		v = convertRVToType(rv, comp.convertTo)
+		exact = false
 	}
 
-	bz, err := si.encoder.Encode(v)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode value: %w", err)
+	var bz []byte
+	if comp.varint {
+		var err error
+		bz, err = encodeVarint(v)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		var err error
+		bz, err = si.encoder.Encode(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to encode value: %w", err)
+		}
+		if comp.terminated {
+			bz = lex.EncodeTerminatedDesc(bz)
+		} else {
+			exact = exact && len(bz) <= comp.size
+			bz = be.PadOrTruncRight(bz, comp.size)
+		}
 	}
-	bz = be.PadOrTruncRight(bz, comp.size)
 
-	if comp.descending {
+	if comp.descending && !comp.terminated {
 		bz = lex.Invert(bz)
 	}
 
@@ -157,7 +201,27 @@ func (si *Indexer[T]) encodeSingleRV(comp Component, rv reflect.Value) ([]byte,
 		bz = append([]byte{byte(k)}, bz...)
 	}
 
-	return bz, nil
+	return bz, exact, nil
+}
+
+// encodeVarint encodes the integer v using the varint encoding, nil pointers are encoded as zero.
+func encodeVarint(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return lex.EncodeVarint(0), nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lex.EncodeVarint(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return lex.EncodeUvarint(rv.Uint()), nil
+	default:
+		return nil, fmt.Errorf("can't encode %T as varint", v)
+	}
 }
 
 func convertRVToType(rv reflect.Value, t reflect.Type) any {
@@ -198,56 +262,115 @@ func (si *Indexer[T]) Lookup(args ...any) (badgerutils.Iterator[[]byte, expr.Ran
 			e = expr.NewRange[any](nil, nil)
 		}
 
-		switch e := e.(type) {
-		case expr.Exact[any]:
-			rv := reflect.ValueOf(e.Value())
-			v, err := si.encodeSingleRV(comp, rv)
+		ranges, _, err := si.encodeExpr(comp, e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode expression for %s: %w", comp.path, err)
+		}
+		pars = expandRanges(pars, ranges...)
+	}
+
+	return iters.Slice(pars), nil
+}
+
+// encodeExpr encodes the expression of a component to the set of ranges that the component value must fall in.
+// The returned flag reports whether the ranges are exact, i.e. they don't contain any value that doesn't satisfy
+// the expression because of truncation or conversion of the values. Only the exact ranges can be complemented.
+func (si *Indexer[T]) encodeExpr(comp Component, e any) ([]expr.Range[[]byte], bool, error) {
+	switch e := e.(type) {
+	case expr.Exact[any]:
+		v, exact, err := si.encodeValue(comp, reflect.ValueOf(e.Value()))
+		if err != nil {
+			return nil, false, err
+		}
+		return []expr.Range[[]byte]{expr.NewRange(expr.NewBound(v, false), expr.NewBound(v, false))}, exact, nil
+	case expr.NotEqual[any]:
+		return si.encodeExpr(comp, expr.NewNot(expr.NewExact(e.Value())))
+	case expr.Range[any]:
+		r, exact, err := si.encodeRange(comp, e)
+		if err != nil {
+			return nil, false, err
+		}
+		return []expr.Range[[]byte]{r}, exact, nil
+	case expr.Set[any]:
+		ranges := make([]expr.Range[[]byte], 0, len(e.Values()))
+		exact := true
+		for _, v := range e.Values() {
+			bz, ok, err := si.encodeValue(comp, reflect.ValueOf(v))
 			if err != nil {
-				return nil, fmt.Errorf("failed to encode value for %s: %w", comp.path, err)
+				return nil, false, err
 			}
-
-			pars = expandRanges(pars, expr.NewRange(expr.NewBound(v, false), expr.NewBound(v, false)))
-		case expr.Range[any]:
-			r, err := si.encodeRange(comp, e)
+			ranges = append(ranges, expr.NewRange(expr.NewBound(bz, false), expr.NewBound(bz, false)))
+			exact = exact && ok
+		}
+		return ranges, exact, nil
+	case expr.Prefix[any]:
+		r, exact, err := si.encodePrefix(comp, e.Value())
+		if err != nil {
+			return nil, false, err
+		}
+		return []expr.Range[[]byte]{r}, exact, nil
+	case expr.Or:
+		var ranges []expr.Range[[]byte]
+		exact := true
+		for _, sub := range e.Expressions() {
+			rs, ok, err := si.encodeExpr(comp, sub)
 			if err != nil {
-				return nil, fmt.Errorf("failed to encode range for %s: %w", comp.path, err)
+				return nil, false, err
 			}
-
-			pars = expandRanges(pars, r)
-		case expr.Set[any]:
-			ranges := make([]expr.Range[[]byte], 0, len(e.Values()))
-			for _, v := range e.Values() {
-				rv := reflect.ValueOf(v)
-				bz, err := si.encodeSingleRV(comp, rv)
-				if err != nil {
-					return nil, fmt.Errorf("failed to encode value for %s: %w", comp.path, err)
-				}
-				ranges = append(ranges, expr.NewRange(expr.NewBound(bz, false), expr.NewBound(bz, false)))
+			ranges = append(ranges, rs...)
+			exact = exact && ok
+		}
+		return indexing.NormalizeChunks(ranges), exact, nil
+	case expr.And:
+		ranges := []expr.Range[[]byte]{si.domain(comp)}
+		exact := true
+		for _, sub := range e.Expressions() {
+			rs, ok, err := si.encodeExpr(comp, sub)
+			if err != nil {
+				return nil, false, err
 			}
-
-			pars = expandRanges(pars, ranges...)
-		default:
-			return nil, fmt.Errorf("unsupported expression type %T", e)
+			ranges = indexing.IntersectChunks(ranges, rs)
+			exact = exact && ok
+		}
+		return ranges, exact, nil
+	case expr.Not:
+		rs, exact, err := si.encodeExpr(comp, e.Expression())
+		if err != nil {
+			return nil, false, err
+		}
+		if !exact {
+			// The complement of an inexact set of ranges would miss some of the matching values.
+			return []expr.Range[[]byte]{si.domain(comp)}, false, nil
 		}
+		return indexing.IntersectChunks(indexing.ComplementChunks(rs), []expr.Range[[]byte]{si.domain(comp)}), true, nil
+	default:
+		return nil, false, fmt.Errorf("%w: expression type %T", indexing.ErrUnsupportedQuery, e)
 	}
+}
 
-	return iters.Slice(pars), nil
+// domain returns the range of all of the possible encoded values of the component.
+func (si *Indexer[T]) domain(comp Component) expr.Range[[]byte] {
+	n := comp.boundSize()
+	if comp.typed {
+		n++
+	}
+	return expr.NewRange(expr.NewBound(make([]byte, n), false), expr.NewBound(bytes.Repeat([]byte{0xff}, n), false))
 }
 
 func (si *Indexer[T]) verifyExprs(args []any) (map[string]any, error) {
 	if len(args) > len(si.components) {
-		return nil, fmt.Errorf("too many arguments %d, expected %d", len(args), len(si.components))
+		return nil, fmt.Errorf("%w: too many arguments %d, expected %d", indexing.ErrUnsupportedQuery, len(args), len(si.components))
 	}
 
 	exs := make(map[string]any)
 	for _, arg := range args {
 		e, ok := arg.(expr.Assigned)
 		if !ok {
-			return nil, fmt.Errorf("unsupported argument type %T", arg)
+			return nil, fmt.Errorf("%w: argument type %T", indexing.ErrUnsupportedQuery, arg)
 		}
 
 		if si.findComponent(e.Name()) == nil {
-			return nil, fmt.Errorf("unsupported path %s", e.Name())
+			return nil, fmt.Errorf("%w: path %s", indexing.ErrUnsupportedQuery, e.Name())
 		}
 
 		if old, ok := exs[e.Name()]; ok {
@@ -258,39 +381,112 @@ func (si *Indexer[T]) verifyExprs(args []any) (map[string]any, error) {
 	return exs, nil
 }
 
-func (si *Indexer[T]) encodeRange(comp Component, r expr.Range[any]) (expr.Range[[]byte], error) {
+func (si *Indexer[T]) encodeRange(comp Component, r expr.Range[any]) (expr.Range[[]byte], bool, error) {
+	n := comp.boundSize()
+	if comp.typed {
+		n++
+	}
+
+	exact := true
+	lowExc, highExc := r.Low().Exclusive(), r.High().Exclusive()
 	var low, high []byte
 	if r.Low().IsEmpty() {
 		if comp.descending {
-			low = bytes.Repeat([]byte{0xff}, comp.size)
+			low = bytes.Repeat([]byte{0xff}, n)
 		} else {
-			low = make([]byte, comp.size)
+			low = make([]byte, n)
 		}
 	} else {
-		var err error
-		rv := reflect.ValueOf(r.Low().Value())
-		low, err = si.encodeSingleRV(comp, rv)
+		var (
+			ok  bool
+			err error
+		)
+		low, ok, err = si.encodeValue(comp, reflect.ValueOf(r.Low().Value()))
 		if err != nil {
-			return expr.Range[[]byte]{}, fmt.Errorf("failed to encode low value: %w", err)
+			return expr.Range[[]byte]{}, false, fmt.Errorf("failed to encode low value: %w", err)
 		}
+		// Other values may share the same lossy encoding so the bound can't be exclusive.
+		lowExc = lowExc && ok
+		exact = exact && ok
 	}
 
 	if r.High().IsEmpty() {
 		if comp.descending {
-			high = make([]byte, comp.size)
+			high = make([]byte, n)
 		} else {
-			high = bytes.Repeat([]byte{0xff}, comp.size)
+			high = bytes.Repeat([]byte{0xff}, n)
 		}
 	} else {
-		var err error
-		rv := reflect.ValueOf(r.High().Value())
-		high, err = si.encodeSingleRV(comp, rv)
+		var (
+			ok  bool
+			err error
+		)
+		high, ok, err = si.encodeValue(comp, reflect.ValueOf(r.High().Value()))
 		if err != nil {
-			return expr.Range[[]byte]{}, fmt.Errorf("failed to encode high value: %w", err)
+			return expr.Range[[]byte]{}, false, fmt.Errorf("failed to encode high value: %w", err)
+		}
+		highExc = highExc && ok
+		exact = exact && ok
+	}
+
+	if comp.descending {
+		// Inverting the values reverses their order so the bounds must be swapped too.
+		return expr.NewRange(expr.NewBound(high, highExc), expr.NewBound(low, lowExc)), exact, nil
+	}
+	return expr.NewRange(expr.NewBound(low, lowExc), expr.NewBound(high, highExc)), exact, nil
+}
+
+// encodePrefix encodes the range of component values that start with the given prefix.
+// The prefix is not padded, instead the range spans from the prefix padded with 0x00 to the prefix padded with 0xff
+// which is the same as [prefix, lex.Increment(prefix)) for the fixed size components.
+func (si *Indexer[T]) encodePrefix(comp Component, v any) (expr.Range[[]byte], bool, error) {
+	if comp.varint {
+		return expr.Range[[]byte]{}, false, fmt.Errorf("%w: prefix on varint component %s", indexing.ErrUnsupportedQuery, comp.path)
+	}
+
+	rv := reflect.ValueOf(v)
+	exact := true
+	if comp.convertTo != nil {
+		v = convertRVToType(rv, comp.convertTo)
+		exact = false
+	}
+
+	bz, err := si.encoder.Encode(v)
+	if err != nil {
+		return expr.Range[[]byte]{}, false, fmt.Errorf("failed to encode value: %w", err)
+	}
+	if len(bz) > comp.size && !comp.terminated {
+		bz = bz[:comp.size]
+		exact = false
+	}
+	var low, high []byte
+	switch {
+	case comp.terminated:
+		// The escaped prefix without the terminator is a prefix of the encoded values and
+		// it's followed by at most 0xff 0xfe.
+		bz = lex.EncodeTerminatedDesc(bz)
+		bz = bz[:len(bz)-2]
+		low = bz
+		high = slices.Concat(bz, []byte{0xff, 0xff})
+	case comp.descending:
+		// Inverting the prefix keeps it a prefix of the inverted values.
+		bz = lex.Invert(bz)
+		fallthrough
+	default:
+		pad := comp.size - len(bz)
+		low = slices.Concat(bz, make([]byte, pad))
+		high = slices.Concat(bz, bytes.Repeat([]byte{0xff}, pad))
+	}
+	if comp.typed {
+		k := rv.Kind()
+		if k == reflect.Ptr && rv.IsNil() {
+			k = reflect.Invalid
 		}
+		low = append([]byte{byte(k)}, low...)
+		high = append([]byte{byte(k)}, high...)
 	}
 
-	return expr.NewRange(expr.NewBound(low, r.Low().Exclusive()), expr.NewBound(high, r.High().Exclusive())), nil
+	return expr.NewRange(expr.NewBound(low, false), expr.NewBound(high, false)), exact, nil
 }
 
 func (si *Indexer[T]) findComponent(path string) *Component {
@@ -320,25 +516,296 @@ func appendRange(p1 expr.Range[[]byte], p2 expr.Range[[]byte]) expr.Range[[]byte
 	p1Low := p1.Low()
 	p1High := p1.High()
 	if p1.Low().Exclusive() && !p1Low.IsEmpty() {
-		p1Low = expr.NewBound(lex.Increment(p1Low.Value()), false)
+		p1Low = expr.NewBound(lex.Increment(bytes.Clone(p1Low.Value())), false)
 	}
 	if p1.High().Exclusive() && !p1High.IsEmpty() {
-		p1High = expr.NewBound(lex.Decrement(p1High.Value()), false)
+		p1High = expr.NewBound(lex.Decrement(bytes.Clone(p1High.Value())), false)
 	}
 	p1 = expr.NewRange(p1Low, p1High)
 
 	return expr.NewRange(
 		expr.NewBound(
-			append(p1.Low().Value(), p2.Low().Value()...),
+			slices.Concat(p1.Low().Value(), p2.Low().Value()),
 			p2.Low().Exclusive(),
 		),
 		expr.NewBound(
-			append(p1.High().Value(), p2.High().Value()...),
+			slices.Concat(p1.High().Value(), p2.High().Value()),
 			p2.High().Exclusive(),
 		),
 	)
 }
 
+// Changed implements the indexing.ChangeDetector interface.
+func (si *Indexer[T]) Changed(old, new *T) (bool, error) {
+	paths := make([]string, 0, len(si.components))
+	for _, comp := range si.components {
+		paths = append(paths, comp.path)
+	}
+
+	return indexing.PathsChanged(si.extractor, old, new, paths...)
+}
+
+// Fingerprint implements the indexing.Fingerprinter interface.
+func (si *Indexer[T]) Fingerprint() []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "concat/v1;%T", si.encoder)
+	for _, comp := range si.components {
+		fmt.Fprintf(h, ";%s,%t,%d,%t,%v", comp.path, comp.typed, comp.size, comp.descending, comp.convertTo)
+		if comp.varint {
+			fmt.Fprint(h, ",varint")
+		}
+		if comp.terminated {
+			fmt.Fprint(h, ",terminated")
+		}
+	}
+	return h.Sum(nil)
+}
+
+// SplitKey implements the indexing.ComponentSplitter interface.
+func (si *Indexer[T]) SplitKey(key []byte) ([][]byte, error) {
+	comps := make([][]byte, 0, len(si.components))
+	for _, comp := range si.components {
+		n := comp.size
+		switch {
+		case comp.varint:
+			n = varintLen(comp, key)
+		case comp.terminated:
+			n = terminatedLen(comp, key)
+		}
+		if comp.typed {
+			n++
+		}
+		if len(key) < n || n == 0 {
+			return nil, fmt.Errorf("key is too short for component %s", comp.path)
+		}
+
+		comps = append(comps, key[:n])
+		key = key[n:]
+	}
+	if len(key) != 0 {
+		return nil, fmt.Errorf("key has %d extra bytes", len(key))
+	}
+
+	return comps, nil
+}
+
+// varintLen returns the length of the varint component at the beginning of key or 0 if it's invalid.
+func varintLen(comp Component, key []byte) int {
+	if comp.typed {
+		if len(key) == 0 {
+			return 0
+		}
+		key = key[1:]
+	}
+	if comp.descending {
+		key = lex.Invert(bytes.Clone(key[:min(len(key), lex.MaxVarintLen)]))
+	}
+	return lex.VarintLen(key)
+}
+
+// terminatedLen returns the length of the terminated component at the beginning of key or 0 if it's invalid.
+func terminatedLen(comp Component, key []byte) int {
+	if comp.typed {
+		if len(key) == 0 {
+			return 0
+		}
+		key = key[1:]
+	}
+	_, n, err := lex.DecodeTerminatedDesc(key)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// DecodeKey decodes an index key into the values of its components.
+// Keys can only be decoded if the encoder of the indexer is a lex.Encoder or implements Decode(bz []byte, v any) error.
+// Strings and byte slices are returned without their zero padding and can't be recovered if they're truncated.
+// Nil pointers are only distinguishable from the pointers to zero values in Typed components.
+func (si *Indexer[T]) DecodeKey(key []byte) ([]any, error) {
+	var dec valueDecoder
+	switch e := si.encoder.(type) {
+	case valueDecoder:
+		dec = e
+	case *lex.Encoder:
+		dec = &lex.Decoder{}
+	default:
+		return nil, fmt.Errorf("encoder %T doesn't support decoding", si.encoder)
+	}
+
+	comps, err := si.SplitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, 0, len(comps))
+	for i, comp := range si.components {
+		v, err := si.decodeComponent(dec, comp, comps[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode component %s: %w", comp.path, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type valueDecoder interface {
+	Decode(bz []byte, v any) error
+}
+
+func (si *Indexer[T]) decodeComponent(dec valueDecoder, comp Component, bz []byte) (any, error) {
+	t, err := si.componentType(comp)
+	if err != nil {
+		return nil, err
+	}
+
+	if comp.typed {
+		k := reflect.Kind(bz[0])
+		bz = bz[1:]
+		if k == reflect.Invalid {
+			return reflect.Zero(t).Interface(), nil
+		}
+		if t.Kind() == reflect.Interface {
+			if t = kindTypes[k]; t == nil {
+				return nil, fmt.Errorf("unsupported kind %s", k)
+			}
+		}
+	}
+	if comp.terminated {
+		bz, _, err = lex.DecodeTerminatedDesc(bz)
+		if err != nil {
+			return nil, err
+		}
+	} else if comp.descending {
+		bz = lex.Invert(bytes.Clone(bz))
+	}
+	if comp.varint {
+		return decodeVarint(t, bz)
+	}
+
+	et := t
+	for et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+	if comp.terminated {
+		// Terminated values are not padded.
+	} else if n := lex.EncodedSizeOf(et); n > 0 {
+		if len(bz) < n {
+			return nil, fmt.Errorf("%s value is truncated to %d bytes", et, len(bz))
+		}
+		bz = bz[:n]
+	} else if isRawBytes(et) {
+		bz = bytes.TrimRight(bz, "\x00")
+	}
+	if !comp.typed && t.Kind() == reflect.Pointer && len(bytes.Trim(bz, "\x00")) == 0 {
+		return reflect.Zero(t).Interface(), nil
+	}
+
+	v := reflect.New(et).Elem()
+	if err := dec.Decode(bz, v); err != nil {
+		return nil, err
+	}
+	for v.Type() != t {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	return v.Interface(), nil
+}
+
+// decodeVarint decodes the varint bz into a value of the integer type t.
+func decodeVarint(t reflect.Type, bz []byte) (any, error) {
+	et := t
+	for et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+
+	v := reflect.New(et).Elem()
+	switch et.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, n := lex.DecodeVarint(bz)
+		if n <= 0 || v.OverflowInt(i) {
+			return nil, fmt.Errorf("invalid varint of %s: %x", et, bz)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, n := lex.DecodeUvarint(bz)
+		if n <= 0 || v.OverflowUint(u) {
+			return nil, fmt.Errorf("invalid varint of %s: %x", et, bz)
+		}
+		v.SetUint(u)
+	default:
+		return nil, fmt.Errorf("can't decode varint as %s", et)
+	}
+
+	for v.Type() != t {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	return v.Interface(), nil
+}
+
+// isRawBytes reports whether the values of the type are encoded as is, so their padding must be trimmed.
+// Other variable-sized encodings are self-delimiting and ignore the padding.
+func isRawBytes(t reflect.Type) bool {
+	if t == decimalType || reflect.PointerTo(t).Implements(lexUnmarshalerType) {
+		return false
+	}
+	return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
+
+// componentType returns the type of the values of the component.
+func (si *Indexer[T]) componentType(comp Component) (reflect.Type, error) {
+	if comp.convertTo != nil {
+		return comp.convertTo, nil
+	}
+
+	t := reflect.TypeFor[T]()
+	for _, part := range strings.Split(comp.path, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("can't find the type of path %s in %s", comp.path, t)
+		}
+		f, ok := t.FieldByName(part)
+		if !ok {
+			return nil, fmt.Errorf("field %s not found in %s", part, t)
+		}
+		t = f.Type
+	}
+
+	if (t.Kind() == reflect.Array || t.Kind() == reflect.Slice) && t.Elem().Kind() != reflect.Uint8 {
+		// Each element of arrays is indexed separately.
+		t = t.Elem()
+	}
+	return t, nil
+}
+
+var (
+	decimalType        = reflect.TypeFor[lex.Decimal]()
+	lexUnmarshalerType = reflect.TypeFor[lex.LexUnmarshaler]()
+)
+
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeFor[bool](),
+	reflect.Int:     reflect.TypeFor[int](),
+	reflect.Int8:    reflect.TypeFor[int8](),
+	reflect.Int16:   reflect.TypeFor[int16](),
+	reflect.Int32:   reflect.TypeFor[int32](),
+	reflect.Int64:   reflect.TypeFor[int64](),
+	reflect.Uint:    reflect.TypeFor[uint](),
+	reflect.Uint8:   reflect.TypeFor[uint8](),
+	reflect.Uint16:  reflect.TypeFor[uint16](),
+	reflect.Uint32:  reflect.TypeFor[uint32](),
+	reflect.Uint64:  reflect.TypeFor[uint64](),
+	reflect.Float32: reflect.TypeFor[float32](),
+	reflect.Float64: reflect.TypeFor[float64](),
+	reflect.String:  reflect.TypeFor[string](),
+	reflect.Slice:   reflect.TypeFor[[]byte](),
+}
+
 // SupportedQueries implements the Indexer interface.
 func (si *Indexer[T]) SupportedQueries() []string {
 	return si.queries
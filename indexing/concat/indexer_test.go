@@ -3,7 +3,10 @@ package concat_test
 import (
 	"bytes"
 	"math"
+	"math/big"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ehsanranjbar/badgerutils"
 	"github.com/ehsanranjbar/badgerutils/codec/be"
@@ -13,6 +16,7 @@ import (
 	"github.com/ehsanranjbar/badgerutils/indexing/concat"
 	"github.com/ehsanranjbar/badgerutils/iters"
 	"github.com/ehsanranjbar/badgerutils/schema"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -247,6 +251,144 @@ func TestIndexer_Lookup(t *testing.T) {
 				),
 			},
 		},
+		{
+			name:       "Prefix",
+			components: []concat.Component{concat.NewComponent("Str1").WithSize(4)},
+			args:       []any{expr.NewAssigned("Str1", expr.NewPrefix[any]("ab"))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound([]byte{'a', 'b', 0x00, 0x00}, false),
+					expr.NewBound([]byte{'a', 'b', 0xff, 0xff}, false),
+				),
+			},
+		},
+		{
+			name:       "Prefix longer than size",
+			components: []concat.Component{concat.NewComponent("Str1").WithSize(2)},
+			args:       []any{expr.NewAssigned("Str1", expr.NewPrefix[any]("abc"))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound([]byte("ab"), false),
+					expr.NewBound([]byte("ab"), false),
+				),
+			},
+		},
+		{
+			name:       "Prefix descending typed",
+			components: []concat.Component{concat.NewComponent("Str1").WithSize(4).Desc().Typed()},
+			args:       []any{expr.NewAssigned("Str1", expr.NewPrefix[any]("ab"))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound([]byte{byte(reflect.String), ^byte('a'), ^byte('b'), 0x00, 0x00}, false),
+					expr.NewBound([]byte{byte(reflect.String), ^byte('a'), ^byte('b'), 0xff, 0xff}, false),
+				),
+			},
+		},
+		{
+			name: "Prefix followed by component",
+			components: []concat.Component{
+				concat.NewComponent("Str1").WithSize(4),
+				concat.NewComponent("Int").WithSize(8),
+			},
+			args: []any{
+				expr.NewAssigned("Str1", expr.NewPrefix[any]("ab")),
+				expr.NewAssigned("Int", expr.NewExact[any](int(30))),
+			},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(append([]byte{'a', 'b', 0x00, 0x00}, lex.EncodeInt64(30)...), false),
+					expr.NewBound(append([]byte{'a', 'b', 0xff, 0xff}, lex.EncodeInt64(30)...), false),
+				),
+			},
+		},
+		{
+			name:       "Not equal",
+			components: []concat.Component{concat.NewComponent("Int").WithSize(8)},
+			args:       []any{expr.NewAssigned("Int", expr.NewNotEqual[any](int(5)))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(make([]byte, 8), false),
+					expr.NewBound(lex.EncodeInt64(5), true),
+				),
+				indexing.NewChunk(
+					expr.NewBound(lex.EncodeInt64(5), true),
+					expr.NewBound(bytes.Repeat([]byte{0xff}, 8), false),
+				),
+			},
+		},
+		{
+			name:       "Or",
+			components: []concat.Component{concat.NewComponent("Int").WithSize(8)},
+			args: []any{expr.NewAssigned("Int", expr.NewOr(
+				expr.NewRange[any](expr.NewBound[any](int(20), false), expr.NewBound[any](int(30), false)),
+				expr.NewExact[any](int(10)),
+				expr.NewRange[any](expr.NewBound[any](int(25), false), expr.NewBound[any](int(40), true)),
+			))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(lex.EncodeInt64(10), false),
+					expr.NewBound(lex.EncodeInt64(10), false),
+				),
+				indexing.NewChunk(
+					expr.NewBound(lex.EncodeInt64(20), false),
+					expr.NewBound(lex.EncodeInt64(40), true),
+				),
+			},
+		},
+		{
+			name:       "And",
+			components: []concat.Component{concat.NewComponent("Int").WithSize(8)},
+			args: []any{expr.NewAssigned("Int", expr.NewAnd(
+				expr.NewRange[any](expr.NewBound[any](int(10), false), expr.NewBound[any](int(30), false)),
+				expr.NewNot(expr.NewSet[any](int(15), int(20))),
+			))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(lex.EncodeInt64(10), false),
+					expr.NewBound(lex.EncodeInt64(15), true),
+				),
+				indexing.NewChunk(
+					expr.NewBound(lex.EncodeInt64(15), true),
+					expr.NewBound(lex.EncodeInt64(20), true),
+				),
+				indexing.NewChunk(
+					expr.NewBound(lex.EncodeInt64(20), true),
+					expr.NewBound(lex.EncodeInt64(30), false),
+				),
+			},
+		},
+		{
+			name:       "Not of truncated value",
+			components: []concat.Component{concat.NewComponent("Str1").WithSize(2)},
+			args:       []any{expr.NewAssigned("Str1", expr.NewNot(expr.NewExact[any]("abc")))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound([]byte{0x00, 0x00}, false),
+					expr.NewBound([]byte{0xff, 0xff}, false),
+				),
+			},
+		},
+		{
+			name: "Not equal followed by component",
+			components: []concat.Component{
+				concat.NewComponent("Int").WithSize(8),
+				concat.NewComponent("Float").WithSize(8),
+			},
+			args: []any{
+				expr.NewAssigned("Int", expr.NewNotEqual[any](int(5))),
+				expr.NewAssigned("Float", expr.NewExact[any](float64(1))),
+			},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(append(make([]byte, 8), lex.EncodeFloat64(1)...), false),
+					expr.NewBound(append(lex.EncodeInt64(4), lex.EncodeFloat64(1)...), false),
+				),
+				indexing.NewChunk(
+					expr.NewBound(append(lex.EncodeInt64(6), lex.EncodeFloat64(1)...), false),
+					expr.NewBound(append(bytes.Repeat([]byte{0xff}, 8), lex.EncodeFloat64(1)...), false),
+				),
+			},
+		},
 		{
 			name:       "Omitted component",
 			components: []concat.Component{concat.NewComponent("Str1"), concat.NewComponent("Str2")},
@@ -291,6 +433,38 @@ func TestIndexer_Lookup(t *testing.T) {
 				),
 			},
 		},
+		{
+			name:       "Descending range",
+			components: []concat.Component{concat.NewComponent("Int").WithSize(8).Desc()},
+			args: []any{expr.NewAssigned(
+				"Int",
+				expr.NewRange(
+					expr.NewBound[any](int64(10), false),
+					expr.NewBound[any](int64(20), false),
+				),
+			)},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(lex.Invert(lex.EncodeInt64(20)), false),
+					expr.NewBound(lex.Invert(lex.EncodeInt64(10)), false),
+				),
+			},
+		},
+		{
+			name: "Omitted descending and typed components",
+			components: []concat.Component{
+				concat.NewComponent("Int").WithSize(8),
+				concat.NewComponent("Float").WithSize(8).Desc(),
+				concat.NewComponent("Str1").WithSize(4).Typed(),
+			},
+			args: []any{expr.NewAssigned("Int", expr.NewExact[any](int64(5)))},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(append(lex.EncodeInt64(5), make([]byte, 8+5)...), false),
+					expr.NewBound(append(lex.EncodeInt64(5), bytes.Repeat([]byte{0xff}, 8+5)...), false),
+				),
+			},
+		},
 		{
 			name:       "Slice equal",
 			components: []concat.Component{concat.NewComponent("StrSlice")},
@@ -302,6 +476,56 @@ func TestIndexer_Lookup(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "Descending string followed by component",
+			components: []concat.Component{
+				concat.NewComponent("Str1").Desc().Terminated(),
+				concat.NewComponent("Int").WithSize(8),
+			},
+			args: []any{
+				expr.NewAssigned("Str1", expr.NewExact[any]("ab")),
+				expr.NewAssigned("Int", expr.NewRange[any](expr.NewBound[any](int(30), false), nil)),
+			},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(append(lex.EncodeTerminatedDesc([]byte("ab")), lex.EncodeInt64(30)...), false),
+					expr.NewBound(append(lex.EncodeTerminatedDesc([]byte("ab")), bytes.Repeat([]byte{0xff}, 8)...), false),
+				),
+			},
+		},
+		{
+			name:       "Varint range",
+			components: []concat.Component{concat.NewComponent("Int").Varint(), concat.NewComponent("Str1").WithSize(4)},
+			args: []any{
+				expr.NewAssigned("Int", expr.NewRange(expr.NewBound[any](-1, false), expr.NewBound[any](300, true))),
+			},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(append(lex.EncodeVarint(-1), 0, 0, 0, 0), false),
+					// The exclusive high bound is decremented to be inclusive for the next component.
+					expr.NewBound(append(lex.EncodeVarint(299), 0xff, 0xff, 0xff, 0xff), false),
+				),
+			},
+		},
+		{
+			name:       "Varint unbounded",
+			components: []concat.Component{concat.NewComponent("Int").Varint().Desc()},
+			args: []any{
+				expr.NewAssigned("Int", expr.NewRange[any](nil, expr.NewBound[any](0, false))),
+			},
+			want: []indexing.Chunk{
+				indexing.NewChunk(
+					expr.NewBound(lex.Invert(lex.EncodeVarint(0)), false),
+					expr.NewBound([]byte{0xff}, false),
+				),
+			},
+		},
+		{
+			name:       "Varint prefix",
+			components: []concat.Component{concat.NewComponent("Int").Varint()},
+			args:       []any{expr.NewAssigned("Int", expr.NewPrefix[any](1))},
+			wantErr:    true,
+		},
 		{
 			name:       "Non-existing field",
 			components: []concat.Component{concat.NewComponent("Str1")},
@@ -363,14 +587,14 @@ func TestIndexer_SupportedExprs(t *testing.T) {
 		{
 			name:       "Single component",
 			components: []concat.Component{concat.NewComponent("Str1")},
-			want:       []string{"queryable(Str1, '=,>,>=,<,<=')"},
+			want:       []string{"queryable(Str1, '=,!=,>,>=,<,<=,prefix')"},
 		},
 		{
 			name:       "Multiple components",
 			components: []concat.Component{concat.NewComponent("Str2"), concat.NewComponent("Int").WithSize(8)},
 			want: []string{
-				"queryable(Str2, '=,>,>=,<,<=')",
-				"queryable(Str2, '=') and queryable(Int, '=,>,>=,<,<=')",
+				"queryable(Str2, '=,!=,>,>=,<,<=,prefix')",
+				"queryable(Str2, '=') and queryable(Int, '=,!=,>,>=,<,<=,prefix')",
 			},
 		},
 	}
@@ -390,3 +614,215 @@ func TestIndexer_SupportedExprs(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexer_SplitKey(t *testing.T) {
+	indexer, err := concat.New(
+		schema.NewReflectPathExtractor[Foo](false),
+		&lex.Encoder{},
+		concat.NewComponent("Str1").WithSize(4),
+		concat.NewComponent("Int").WithSize(8).Typed(),
+	)
+	require.NoError(t, err)
+
+	kvs, err := indexer.Index(&Foo{Str1: "ab", Int: 5}, true)
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+
+	comps, err := indexer.SplitKey(kvs[0].Key)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		be.PadOrTruncRight([]byte("ab"), 4),
+		append([]byte{byte(reflect.Int)}, lex.EncodeInt64(5)...),
+	}, comps)
+
+	_, err = indexer.SplitKey(kvs[0].Key[:10])
+	require.Error(t, err)
+	_, err = indexer.SplitKey(append(kvs[0].Key, 0))
+	require.Error(t, err)
+}
+
+func TestIndexer_DecodeKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []concat.Component
+		value      Foo
+		want       [][]any
+		wantErr    bool
+	}{
+		{
+			name: "Scalars",
+			components: []concat.Component{
+				concat.NewComponent("Str1").WithSize(8),
+				concat.NewComponent("Int").WithSize(8).Desc(),
+				concat.NewComponent("Float").WithSize(8).Typed(),
+				concat.NewComponent("Bytes").WithSize(4),
+			},
+			value: Foo{Str1: "Alice", Int: -30, Float: 1.5, Bytes: []byte{0x01}},
+			want:  [][]any{{"Alice", -30, 1.5, []byte{0x01}}},
+		},
+		{
+			name: "Nested and pointers",
+			components: []concat.Component{
+				concat.NewComponent("Struct.Test").WithSize(8),
+				concat.NewComponent("Pointer").WithSize(8).Typed(),
+			},
+			value: Foo{Struct: Bar{Test: 7}},
+			want:  [][]any{{7, (*Bar)(nil)}},
+		},
+		{
+			name:       "Array",
+			components: []concat.Component{concat.NewComponent("Array").WithSize(8)},
+			value:      Foo{Array: [3]int{1, 2, 3}},
+			want:       [][]any{{1}, {2}, {3}},
+		},
+		{
+			name:       "Converted",
+			components: []concat.Component{concat.NewComponent("Int").WithSize(8).AsFloat64()},
+			value:      Foo{Int: 3},
+			want:       [][]any{{float64(3)}},
+		},
+		{
+			name: "Varint",
+			components: []concat.Component{
+				concat.NewComponent("Int").Varint().Desc(),
+				concat.NewComponent("Struct.Test").Varint().Typed(),
+				concat.NewComponent("Str1").WithSize(4),
+			},
+			value: Foo{Int: -300, Struct: Bar{Test: 70000}, Str1: "Bob"},
+			want:  [][]any{{-300, 70000, "Bob"}},
+		},
+		{
+			name: "Descending strings",
+			components: []concat.Component{
+				concat.NewComponent("StrSlice").Desc().Terminated().Typed(),
+				concat.NewComponent("Bytes").WithSize(4).Desc().Terminated(),
+			},
+			value: Foo{StrSlice: []string{"a\x00b", ""}, Bytes: []byte{0x00, 0x01}},
+			want:  [][]any{{"a\x00b", []byte{0x00, 0x01}}, {"", []byte{0x00, 0x01}}},
+		},
+		{
+			name:       "Truncated",
+			components: []concat.Component{concat.NewComponent("Int").WithSize(4)},
+			value:      Foo{Int: 3},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer, err := concat.New(
+				schema.NewReflectPathExtractor[Foo](false),
+				&lex.Encoder{},
+				tt.components...,
+			)
+			require.NoError(t, err)
+
+			pairs, err := indexer.Index(&tt.value, true)
+			require.NoError(t, err)
+
+			var got [][]any
+			for _, p := range pairs {
+				values, err := indexer.DecodeKey(p.Key)
+				if tt.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				got = append(got, values)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIndexer_ExtendedTypes(t *testing.T) {
+	type Record struct {
+		Time    time.Time
+		Id      uuid.UUID
+		Balance *big.Int
+		Price   lex.Decimal
+	}
+
+	indexer, err := concat.New(
+		schema.NewReflectPathExtractor[Record](false),
+		&lex.Encoder{},
+		concat.NewComponent("Time").WithSize(8),
+		concat.NewComponent("Id").WithSize(16),
+		concat.NewComponent("Balance").WithSize(16).Desc(),
+		concat.NewComponent("Price").WithSize(16),
+	)
+	require.NoError(t, err)
+
+	v := Record{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Id:      uuid.New(),
+		Balance: big.NewInt(256),
+		Price:   "10.50",
+	}
+	pairs, err := indexer.Index(&v, true)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+
+	values, err := indexer.DecodeKey(pairs[0].Key)
+	require.NoError(t, err)
+	require.Equal(t, []any{v.Time, v.Id, big.NewInt(256), lex.Decimal("10.5")}, values)
+}
+
+func TestIndexer_DescendingOrder(t *testing.T) {
+	indexer, err := concat.New(
+		schema.NewReflectPathExtractor[Foo](false),
+		&lex.Encoder{},
+		concat.NewComponent("Str1").Desc().Terminated(),
+		concat.NewComponent("Int").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	values := []Foo{
+		{Str1: "b", Int: 1},
+		{Str1: "abc", Int: 1},
+		{Str1: "ab\x00", Int: 1},
+		{Str1: "ab", Int: 0},
+		{Str1: "ab", Int: 1},
+		{Str1: "", Int: 1},
+	}
+	var prev []byte
+	for _, v := range values {
+		pairs, err := indexer.Index(&v, true)
+		require.NoError(t, err)
+		require.Len(t, pairs, 1)
+		require.Negative(t, bytes.Compare(prev, pairs[0].Key), "%q, %d", v.Str1, v.Int)
+		prev = pairs[0].Key
+	}
+
+	t.Run("NotTruncated", func(t *testing.T) {
+		indexer, err := concat.New(
+			schema.NewReflectPathExtractor[Foo](false),
+			&lex.Encoder{},
+			concat.NewComponent("Str1").WithSize(2).Desc().Terminated(),
+		)
+		require.NoError(t, err)
+
+		var keys [][]byte
+		for _, str := range []string{"abd", "abc"} {
+			pairs, err := indexer.Index(&Foo{Str1: str}, true)
+			require.NoError(t, err)
+			require.Len(t, pairs, 1)
+			keys = append(keys, pairs[0].Key)
+
+			values, err := indexer.DecodeKey(pairs[0].Key)
+			require.NoError(t, err)
+			require.Equal(t, []any{str}, values)
+		}
+		require.Negative(t, bytes.Compare(keys[0], keys[1]))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, comp := range []concat.Component{
+			concat.NewComponent("Str1").Terminated(),
+			concat.NewComponent("Int").Desc().Terminated(),
+		} {
+			_, err := concat.New(schema.NewReflectPathExtractor[Foo](false), &lex.Encoder{}, comp)
+			require.Error(t, err)
+		}
+	})
+}
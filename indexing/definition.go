@@ -0,0 +1,169 @@
+package indexing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+)
+
+var (
+	// ErrDefinitionMismatch is returned when the persisted fingerprint of an index doesn't match its indexer.
+	ErrDefinitionMismatch = errors.New("index definition has changed")
+
+	fingerprintKey = []byte("fingerprint")
+)
+
+// Fingerprinter is an optional interface for indexers to describe their definition, i.e. everything that affects
+// the generated keys, so that changes to the definition can be detected against the persisted index.
+// A nil fingerprint means that the definition can't be described.
+type Fingerprinter interface {
+	Fingerprint() []byte
+}
+
+// DefinitionPolicy determines what an index extension does when the definition of its indexer has changed.
+type DefinitionPolicy int
+
+const (
+	// FailOnChange causes Verify to return ErrDefinitionMismatch.
+	FailOnChange DefinitionPolicy = iota
+	// RebuildOnChange makes ext.Rebuild to rebuild the index from the records of the store.
+	// Indexes without a persisted fingerprint are rebuilt too which fills a newly added index.
+	// Verify and the lookups return ErrDefinitionMismatch until the index is rebuilt.
+	RebuildOnChange
+)
+
+// WithDefinitionPolicy sets the policy for handling the changes to the definition of the indexer.
+// It only takes effect if the indexer implements Fingerprinter.
+func WithDefinitionPolicy[T any](p DefinitionPolicy) func(*Extension[T]) {
+	return func(e *Extension[T]) {
+		e.policy = p
+	}
+}
+
+// Verify implements the ext.Verifier interface.
+// It returns ErrDefinitionMismatch if the index is built with another definition of the indexer.
+// The fingerprint of an index without one is persisted unless the policy is RebuildOnChange.
+// Lookups of an index that doesn't pass Verify fail with the same error whether Verify is called or not.
+func (e *Extension[T]) Verify(txn *badger.Txn) error {
+	return e.checkDefinition(e.metaStore.Instantiate(txn), true)
+}
+
+// checkDefinition returns ErrDefinitionMismatch if the index is built with another definition of the indexer or,
+// with RebuildOnChange, isn't built with any yet. Otherwise a missing fingerprint is persisted if adopt is set.
+func (e *Extension[T]) checkDefinition(meta badgerutils.BadgerStore, adopt bool) error {
+	want, got, err := e.fingerprints(meta)
+	if err != nil || want == nil {
+		return err
+	}
+	if got != nil {
+		e.fingerprinted.Store(true)
+	}
+
+	switch {
+	case got == nil && e.policy != RebuildOnChange:
+		if !adopt {
+			return nil
+		}
+		if err := meta.Set(fingerprintKey, want); err != nil {
+			return fmt.Errorf("failed to set fingerprint: %w", err)
+		}
+		return nil
+	case !bytes.Equal(got, want):
+		return ErrDefinitionMismatch
+	}
+	return nil
+}
+
+// NeedsRebuild implements the ext.Rebuilder interface.
+func (e *Extension[T]) NeedsRebuild(txn *badger.Txn) (bool, error) {
+	if e.policy != RebuildOnChange {
+		return false, nil
+	}
+
+	want, got, err := e.fingerprints(e.metaStore.Instantiate(txn))
+	if err != nil {
+		return false, err
+	}
+	return want != nil && !bytes.Equal(got, want), nil
+}
+
+// Drop implements the ext.Rebuilder interface. It drops the refs, the change log and the statistics of the index.
+func (e *Extension[T]) Drop(txn *badger.Txn, n int) (int, error) {
+	ins := e.Instantiate(txn).(*ExtensionInstance[T])
+
+	deleted, err := deleteN(e.refsStore.Instantiate(txn), len(e.store.Prefix()), n)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to drop index: %w", err)
+	}
+	if deleted < n {
+		m, err := deleteN(ins.changesStore, len(e.changesStore.Prefix()), n-deleted)
+		deleted += m
+		if err != nil {
+			return deleted, fmt.Errorf("failed to drop change log: %w", err)
+		}
+	}
+	if deleted < n {
+		if err := ins.statsStore.Delete(statsKey); err != nil {
+			return deleted, fmt.Errorf("failed to drop stats: %w", err)
+		}
+	}
+
+	return deleted, nil
+}
+
+// Rebuilt implements the ext.Rebuilder interface. It persists the fingerprint of the indexer.
+func (e *Extension[T]) Rebuilt(txn *badger.Txn) error {
+	want, _, err := e.fingerprints(e.metaStore.Instantiate(txn))
+	if err != nil || want == nil {
+		return err
+	}
+
+	if err := e.metaStore.Instantiate(txn).Set(fingerprintKey, want); err != nil {
+		return fmt.Errorf("failed to set fingerprint: %w", err)
+	}
+	return nil
+}
+
+// fingerprints returns the fingerprint of the indexer and the persisted one which is nil if there's none.
+// want is nil if the indexer doesn't describe its definition.
+func (e *Extension[T]) fingerprints(meta badgerutils.BadgerStore) (want, got []byte, err error) {
+	fp, ok := e.indexer.(Fingerprinter)
+	if !ok {
+		return nil, nil, nil
+	}
+	want = fp.Fingerprint()
+	if want == nil {
+		return nil, nil, nil
+	}
+
+	item, err := meta.Get(fingerprintKey)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return want, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to get fingerprint: %w", err)
+	}
+
+	got, err = item.ValueCopy(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get fingerprint: %w", err)
+	}
+	return want, got, nil
+}
+
+// deleteN deletes at most n keys of the store and returns the number of deleted keys.
+func deleteN(store badgerutils.BadgerStore, prefixLen, n int) (int, error) {
+	iter := store.NewIterator(badger.IteratorOptions{PrefetchValues: false})
+	defer iter.Close()
+
+	var deleted int
+	for iter.Rewind(); iter.Valid() && deleted < n; iter.Next() {
+		if err := store.Delete(iter.Item().KeyCopy(nil)[prefixLen:]); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,164 @@
+package indexing_test
+
+import (
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+	"github.com/ehsanranjbar/badgerutils/indexing/concat"
+	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/ehsanranjbar/badgerutils/schema"
+	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtension_Verify(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	newStore := func(comp concat.Component, opts ...func(*indexing.Extension[TestStruct])) *extstore.Store[TestStruct, *TestStruct] {
+		indexer, err := concat.New(
+			schema.NewReflectPathExtractor[TestStruct](false),
+			&lex.Encoder{},
+			comp,
+		)
+		require.NoError(t, err)
+
+		return extstore.New[TestStruct](nil).
+			WithExtension("a", indexing.NewExtension(indexer, opts...))
+	}
+	lookup := func(txn *badger.Txn, store *extstore.Store[TestStruct, *TestStruct], a int) [][]byte {
+		extIns := store.Instantiate(txn).GetExtension("a").(*indexing.ExtensionInstance[TestStruct])
+		it, err := extIns.Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("A", expr.NewExact[any](a)))
+		require.NoError(t, err)
+		defer it.Close()
+
+		keys, err := iters.Collect(it)
+		require.NoError(t, err)
+		return keys
+	}
+
+	original := newStore(concat.NewComponent("A").WithSize(8))
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := original.Instantiate(txn)
+		for i := 0; i < 10; i++ {
+			if err := ins.Set([]byte{byte(i)}, &TestStruct{A: i % 3}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	// The fingerprint of an index without one is persisted.
+	require.NoError(t, original.Verify(db))
+
+	t.Run("Unchanged", func(t *testing.T) {
+		store := newStore(concat.NewComponent("A").WithSize(8))
+		require.NoError(t, store.Verify(db))
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			require.Equal(t, [][]byte{{1}, {4}, {7}}, lookup(txn, store, 1))
+			return nil
+		}))
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		store := newStore(concat.NewComponent("A").WithSize(8).Desc())
+		require.ErrorIs(t, store.Verify(db), indexing.ErrDefinitionMismatch)
+
+		n, err := extstore.Rebuild(db, store, 4)
+		require.NoError(t, err)
+		require.Zero(t, n)
+		require.ErrorIs(t, store.Verify(db), indexing.ErrDefinitionMismatch)
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		store := newStore(concat.NewComponent("A").WithSize(8).Desc(), indexing.WithDefinitionPolicy[TestStruct](indexing.RebuildOnChange))
+		require.ErrorIs(t, store.Verify(db), indexing.ErrDefinitionMismatch)
+
+		n, err := extstore.Rebuild(db, store, 4)
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+		require.NoError(t, store.Verify(db))
+
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			require.Equal(t, [][]byte{{2}, {5}, {8}}, lookup(txn, store, 2))
+			require.Equal(t, [][]byte{{1}, {4}, {7}}, lookup(txn, store, 1))
+			return nil
+		}))
+
+		// Nothing is left to rebuild.
+		n, err = extstore.Rebuild(db, store, 4)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		// The original definition doesn't match anymore.
+		require.ErrorIs(t, original.Verify(db), indexing.ErrDefinitionMismatch)
+	})
+
+	t.Run("NewIndex", func(t *testing.T) {
+		indexer, err := concat.New(
+			schema.NewReflectPathExtractor[TestStruct](false),
+			&lex.Encoder{},
+			concat.NewComponent("A").WithSize(8).Typed(),
+		)
+		require.NoError(t, err)
+		store := extstore.New[TestStruct](nil).
+			WithExtension("a", indexing.NewExtension(indexer, indexing.WithDefinitionPolicy[TestStruct](indexing.RebuildOnChange))).
+			WithExtension("b", indexing.NewExtension(tagsIndexer{}))
+
+		n, err := extstore.Rebuild(db, store, 4)
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+		require.NoError(t, store.Verify(db))
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			require.Equal(t, [][]byte{{0}, {3}, {6}, {9}}, lookup(txn, store, 0))
+			return nil
+		}))
+	})
+}
+
+func TestExtensionInstance_LookupUnverified(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	newStore := func(comp concat.Component) *extstore.Store[TestStruct, *TestStruct] {
+		indexer, err := concat.New(
+			schema.NewReflectPathExtractor[TestStruct](false),
+			&lex.Encoder{},
+			comp,
+		)
+		require.NoError(t, err)
+
+		return extstore.New[TestStruct](nil).WithExtension("a", indexing.NewExtension(indexer))
+	}
+	lookup := func(store *extstore.Store[TestStruct, *TestStruct]) (lookupErr, estimateErr error) {
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			extIns := store.Instantiate(txn).GetExtension("a").(*indexing.ExtensionInstance[TestStruct])
+			arg := expr.NewAssigned("A", expr.NewExact[any](1))
+			_, _, estimateErr = extIns.Estimate(arg)
+			it, err := extIns.Lookup(badger.DefaultIteratorOptions, arg)
+			if err == nil {
+				it.Close()
+			}
+			lookupErr = err
+			return nil
+		}))
+		return lookupErr, estimateErr
+	}
+
+	// The fingerprint is persisted on the first write without calling Verify.
+	original := newStore(concat.NewComponent("A").WithSize(8))
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return original.Instantiate(txn).Set([]byte{1}, &TestStruct{A: 1})
+	}))
+	lookupErr, _ := lookup(original)
+	require.NoError(t, lookupErr)
+
+	changed := newStore(concat.NewComponent("A").WithSize(8).Desc())
+	lookupErr, estimateErr := lookup(changed)
+	require.ErrorIs(t, lookupErr, indexing.ErrDefinitionMismatch)
+	require.ErrorIs(t, estimateErr, indexing.ErrDefinitionMismatch)
+}
@@ -1,46 +1,146 @@
 package indexing
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
 
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/iters"
 	"github.com/ehsanranjbar/badgerutils/store/ext"
+	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
 	refstore "github.com/ehsanranjbar/badgerutils/store/ref"
 )
 
+var (
+	statsPrefix   = []byte{'s'}
+	changesPrefix = []byte{'c'}
+	metaPrefix    = []byte{'m'}
+)
+
 // Extension is an extension for extensible stores that indexes the data with a given indexer.
 type Extension[T any] struct {
-	indexer    Indexer[T]
-	descriptor IndexDescriptor
-	store      *refstore.Store
+	indexer      Indexer[T]
+	descriptor   IndexDescriptor
+	splitter     ComponentSplitter
+	trackStats   bool
+	buckets      int
+	async        bool
+	policy       DefinitionPolicy
+	store        *refstore.Store
+	refsStore    badgerutils.Instantiator[badgerutils.BadgerStore]
+	statsStore   *pstore.Store
+	changesStore *pstore.Store
+	metaStore    *pstore.Store
+
+	// fingerprinted is set once the fingerprint of the index is known to be persisted.
+	fingerprinted atomic.Bool
 }
 
 // NewExtension creates a new Extension.
-func NewExtension[T any](indexer Indexer[T]) ext.Extension[T] {
+func NewExtension[T any](indexer Indexer[T], opts ...func(*Extension[T])) ext.Extension[T] {
 	descriptor, _ := indexer.(IndexDescriptor)
-	return &Extension[T]{
+	splitter, _ := indexer.(ComponentSplitter)
+	e := &Extension[T]{
 		indexer:    indexer,
 		descriptor: descriptor,
+		splitter:   splitter,
+		buckets:    DefaultHistogramBuckets,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// WithStatsTracking makes the extension to keep the statistics of the index up to date on every OnSet and OnDelete.
+// As the statistics are stored in a single key, every write to the index conflicts with all other concurrent writes
+// so it's only suitable for low write concurrency. If the index already has entries, Analyze must be called
+// once after enabling it.
+func WithStatsTracking[T any]() func(*Extension[T]) {
+	return func(e *Extension[T]) {
+		e.trackStats = true
+	}
+}
+
+// WithAsync makes the extension to only record the index changes of each write in a change log which is applied
+// to the index later by a Worker, so writes of different records won't conflict on hot index keys.
+// Lookups don't see the writes that are not applied yet, see Worker.WaitFor for read-your-writes.
+// The queries of record stores don't use the index while it has pending changes, see ExtensionInstance.Pending.
+func WithAsync[T any]() func(*Extension[T]) {
+	return func(e *Extension[T]) {
+		e.async = true
+	}
+}
+
+// WithHistogramBuckets sets the number of buckets of the histogram that Analyze builds.
+func WithHistogramBuckets[T any](n int) func(*Extension[T]) {
+	if n <= 0 {
+		panic("number of buckets must be positive")
+	}
+
+	return func(e *Extension[T]) {
+		e.buckets = n
 	}
 }
 
 // Init implements the extensible.Extension interface.
 func (e *Extension[T]) RegisterStore(store badgerutils.Instantiator[badgerutils.BadgerStore]) {
 	e.store = refstore.New(store)
+	e.refsStore = store
+}
+
+// RegisterMetaStore implements the ext.MetaStoreRegistry interface.
+// The statistics, the change log and the definition fingerprint of the index are kept in the meta store.
+func (e *Extension[T]) RegisterMetaStore(store badgerutils.Instantiator[badgerutils.BadgerStore]) {
+	e.statsStore = pstore.New(store, statsPrefix)
+	e.changesStore = pstore.New(store, changesPrefix)
+	e.metaStore = pstore.New(store, metaPrefix)
+}
+
+// BulkEntries implements the ext.BulkLoader interface. The refs are written directly even if the extension
+// is async, and the statistics are not updated, so Analyze should be called after loading.
+func (e *Extension[T]) BulkEntries(key []byte, v *T, opts ...any) ([]*badger.Entry, error) {
+	kvs, err := e.indexer.Index(v, true)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, _ := ext.FindExpiry(opts)
+	_, sets, _ := diffPairs(nil, kvs, false)
+	entries := make([]*badger.Entry, 0, len(sets))
+	for _, kv := range sets {
+		entry, err := e.store.Entry(key, refstore.NewRefEntry(kv.Key).WithValue(kv.Value).WithExpiresAt(exp.ExpiresAt))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
 }
 
 // Instantiate implements the extensible.Extension interface.
 func (e *Extension[T]) Instantiate(txn *badger.Txn) ext.ExtensionInstance[T] {
 	return &ExtensionInstance[T]{
-		ext:   e,
-		store: e.store.Instantiate(txn).(*refstore.Instance),
+		ext:          e,
+		store:        e.store.Instantiate(txn).(*refstore.Instance),
+		statsStore:   e.statsStore.Instantiate(txn),
+		changesStore: e.changesStore.Instantiate(txn),
+		metaStore:    e.metaStore.Instantiate(txn),
 	}
 }
 
 type ExtensionInstance[T any] struct {
-	ext   *Extension[T]
-	store *refstore.Instance
+	ext          *Extension[T]
+	store        *refstore.Instance
+	statsStore   badgerutils.BadgerStore
+	changesStore badgerutils.BadgerStore
+	metaStore    badgerutils.BadgerStore
 }
 
 // OnDelete implements the extensible.Extension interface.
@@ -49,53 +149,243 @@ func (e *ExtensionInstance[T]) OnDelete(_ context.Context, key []byte, value *T)
 	if err != nil {
 		return err
 	}
-	for _, kv := range kvs {
-		err := e.store.Delete(kv.Key)
-		if err != nil {
-			return err
-		}
+
+	if e.ext.async {
+		return e.enqueue(key, kvs, nil, 0, false)
 	}
+	return e.apply(key, kvs, nil, 0, false)
+}
 
-	return nil
+// refKey returns the whole key of a ref so that refs of other records sharing the same index key are not touched.
+func refKey(prefix, key []byte) []byte {
+	return append(bytes.Clone(prefix), key...)
 }
 
 // OnSet implements the extensible.Extension interface.
+// The refs expire along with the record and if its expiry has changed, all of them are rewritten with
+// the new expiry. Refs expiring along with the record are not subtracted from the statistics.
 func (e *ExtensionInstance[T]) OnSet(_ context.Context, key []byte, old, new *T, opts ...any) error {
+	if err := e.adoptDefinition(); err != nil {
+		return err
+	}
+
+	exp, _ := ext.FindExpiry(opts)
+	refresh := exp.Changed()
+	if old != nil && new != nil && !refresh {
+		if cd, ok := e.ext.indexer.(ChangeDetector[T]); ok {
+			changed, err := cd.Changed(old, new)
+			if err != nil {
+				return fmt.Errorf("failed to detect changes: %w", err)
+			}
+			if !changed {
+				return nil
+			}
+		}
+	}
+
+	var olds []badgerutils.RawKVPair
 	if old != nil {
-		kvs, err := e.ext.indexer.Index(old, false)
+		var err error
+		olds, err = e.ext.indexer.Index(old, false)
 		if err != nil {
 			return err
 		}
-		for _, kv := range kvs {
-			err := e.store.Delete(kv.Key)
-			if err != nil {
-				return err
-			}
-		}
 	}
 
-	kvs, err := e.ext.indexer.Index(new, true)
+	news, err := e.ext.indexer.Index(new, true)
 	if err != nil {
 		return err
 	}
-	for _, kv := range kvs {
-		err := e.store.Set(key, refstore.NewRefEntry(kv.Key).WithValue(kv.Value))
+
+	if e.ext.async {
+		return e.enqueue(key, olds, news, exp.ExpiresAt, refresh)
+	}
+	return e.apply(key, olds, news, exp.ExpiresAt, refresh)
+}
+
+// adoptDefinition persists the fingerprint of the indexer on the first write to an index without one, so that
+// the later changes to the definition are detected even if Verify is never called. The writes to an index with
+// another definition go on, e.g. while it's being rebuilt.
+func (e *ExtensionInstance[T]) adoptDefinition() error {
+	if e.ext.fingerprinted.Load() {
+		return nil
+	}
+
+	err := e.ext.checkDefinition(e.metaStore, true)
+	if errors.Is(err, ErrDefinitionMismatch) {
+		return nil
+	}
+	return err
+}
+
+// apply replaces the refs of the key generated from the old value with the ones generated from the new value.
+// The new refs expire at expiresAt and if refresh is set, the ones that exist in both are rewritten as well.
+func (e *ExtensionInstance[T]) apply(key []byte, olds, news []badgerutils.RawKVPair, expiresAt uint64, refresh bool) error {
+	dels, sets, updates := diffPairs(olds, news, refresh)
+	for _, kv := range dels {
+		err := e.store.Delete(refKey(kv.Key, key))
+		if err != nil {
+			return err
+		}
+	}
+	for _, kv := range append(sets, updates...) {
+		err := e.store.Set(key, refstore.NewRefEntry(kv.Key).WithValue(kv.Value).WithExpiresAt(expiresAt))
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return e.updateStats(dels, sets)
+}
+
+// diffPairs compares the index pairs of the old and new values and returns the pairs that must be deleted,
+// the ones that must be added and the ones that exist in both but must be rewritten because they carry a value.
+// Values of the old pairs are not known as they are generated without set, so the ones with a value are always
+// rewritten, and if rewrite is set all of them are.
+func diffPairs(olds, news []badgerutils.RawKVPair, rewrite bool) (dels, sets, updates []badgerutils.RawKVPair) {
+	existing := make(map[string]struct{}, len(olds))
+	for _, kv := range olds {
+		existing[string(kv.Key)] = struct{}{}
+	}
+
+	seen := make(map[string]struct{}, len(news))
+	for _, kv := range news {
+		k := string(kv.Key)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+
+		if _, ok := existing[k]; !ok {
+			sets = append(sets, kv)
+		} else if kv.Value != nil || rewrite {
+			updates = append(updates, kv)
+		}
+	}
+
+	for _, kv := range olds {
+		k := string(kv.Key)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		dels = append(dels, kv)
+	}
+
+	return dels, sets, updates
+}
+
+func (e *ExtensionInstance[T]) updateStats(dels, sets []badgerutils.RawKVPair) error {
+	if !e.ext.trackStats || len(dels)+len(sets) == 0 {
+		return nil
+	}
+
+	r, err := loadStats(e.statsStore)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		r = &statsRecord{}
+	} else if err != nil {
+		return fmt.Errorf("failed to load stats: %w", err)
+	}
+
+	for _, kv := range dels {
+		r.remove(kv.Key)
+	}
+	for _, kv := range sets {
+		leading, err := leadingPrefixes(e.ext.splitter, kv.Key)
+		if err != nil {
+			return err
+		}
+		if err := r.add(leading); err != nil {
+			return err
+		}
+	}
+
+	return saveStats(e.statsStore, r)
 }
 
 // Lookup queries the index with the given arguments and returns an iterator of keys.
+// The keys are returned in the order of the index, reversed if opts.Reverse is set, and each key at most once.
+// It returns ErrDefinitionMismatch if the index doesn't pass Verify, e.g. it's built with another definition.
 func (e *ExtensionInstance[T]) Lookup(opts badger.IteratorOptions, args ...any) (badgerutils.Iterator[[]byte, []byte], error) {
+	if err := e.ext.checkDefinition(e.metaStore, false); err != nil {
+		return nil, err
+	}
+
+	if s, ok := e.ext.indexer.(Searcher); ok {
+		return s.Search(e.store, opts, args...)
+	}
+
 	iter, err := e.ext.indexer.Lookup(args...)
 	if err != nil {
 		return nil, err
 	}
+	defer iter.Close()
+
+	chunks, err := iters.Collect(iter)
+	if err != nil {
+		return nil, err
+	}
+	chunks = NormalizeChunks(chunks)
+	if opts.Reverse {
+		slices.Reverse(chunks)
+	}
+
+	// A key may still be referenced by several index keys in the chunks e.g. when a slice field is indexed.
+	return iters.DistinctValues(LookupChunks(e.store, iters.Slice(chunks), opts)), nil
+}
+
+// Analyze scans the whole index, computes its statistics and stores them.
+func (e *ExtensionInstance[T]) Analyze() error {
+	r, err := analyze(e.store, e.ext.splitter, e.ext.buckets)
+	if err != nil {
+		return fmt.Errorf("failed to analyze index: %w", err)
+	}
+
+	return saveStats(e.statsStore, r)
+}
+
+// Stats returns the statistics of the index as of the last Analyze call or the last write if stats tracking
+// is enabled. It returns ErrNoStats if neither has happened yet.
+func (e *ExtensionInstance[T]) Stats() (*Stats, error) {
+	r, err := loadStats(e.statsStore)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrNoStats
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load stats: %w", err)
+	}
+
+	return r.stats()
+}
+
+// Estimate returns the estimated number of entries of the index that match the given lookup arguments.
+// It returns ErrUnsupportedQuery if the index can't serve the given arguments, ErrNoEstimate if the index
+// doesn't support estimation, ErrNoStats if the statistics are not available and ErrDefinitionMismatch
+// the same as Lookup.
+func (e *ExtensionInstance[T]) Estimate(args ...any) (float64, *Stats, error) {
+	if err := e.ext.checkDefinition(e.metaStore, false); err != nil {
+		return 0, nil, err
+	}
+	if _, ok := e.ext.indexer.(Searcher); ok {
+		return 0, nil, ErrNoEstimate
+	}
+
+	iter, err := e.ext.indexer.Lookup(args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer iter.Close()
+
+	chunks, err := iters.Collect(iter)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	stats, err := e.Stats()
+	if err != nil {
+		return 0, nil, err
+	}
 
-	return LookupChunks(e.store, iter, opts), nil
+	return stats.Estimate(chunks...), stats, nil
 }
 
 // SupportedQueries returns the supported queries of the index.
@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	badger "github.com/dgraph-io/badger/v4"
@@ -12,8 +14,11 @@ import (
 	"github.com/ehsanranjbar/badgerutils/codec/lex"
 	"github.com/ehsanranjbar/badgerutils/expr"
 	"github.com/ehsanranjbar/badgerutils/indexing"
+	"github.com/ehsanranjbar/badgerutils/indexing/concat"
 	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/ehsanranjbar/badgerutils/schema"
 	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
 	"github.com/ehsanranjbar/badgerutils/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -113,3 +118,277 @@ func TestStore(t *testing.T) {
 		require.Equal(t, [][]byte{{2}, {3}, {1}}, actual)
 	})
 }
+
+func TestExtensionInstance_Stats(t *testing.T) {
+	indexer, err := concat.New(
+		schema.NewReflectPathExtractor[TestStruct](false),
+		&lex.Encoder{},
+		concat.NewComponent("A").WithSize(8),
+		concat.NewComponent("B").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	store := extstore.New[TestStruct](nil).
+		WithExtension("analyzed", indexing.NewExtension(indexer, indexing.WithHistogramBuckets[TestStruct](4))).
+		WithExtension("tracked", indexing.NewExtension(indexer, indexing.WithStatsTracking[TestStruct]()))
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+	analyzed := ins.GetExtension("analyzed").(*indexing.ExtensionInstance[TestStruct])
+	tracked := ins.GetExtension("tracked").(*indexing.ExtensionInstance[TestStruct])
+
+	_, err = analyzed.Stats()
+	require.ErrorIs(t, err, indexing.ErrNoStats)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, ins.Set([]byte{byte(i)}, &TestStruct{A: i % 10, B: fmt.Sprint(i)}))
+	}
+
+	t.Run("Analyze", func(t *testing.T) {
+		require.NoError(t, analyzed.Analyze())
+
+		stats, err := analyzed.Stats()
+		require.NoError(t, err)
+		require.Equal(t, int64(100), stats.Entries)
+		require.Len(t, stats.Distinct, 2)
+		require.InEpsilon(t, 10, float64(stats.Distinct[0]), 0.1)
+		require.InEpsilon(t, 100, float64(stats.Distinct[1]), 0.1)
+		require.Len(t, stats.Histogram, 4)
+		for _, b := range stats.Histogram {
+			require.Equal(t, int64(25), b.Count)
+		}
+	})
+
+	t.Run("Estimate", func(t *testing.T) {
+		est, _, err := analyzed.Estimate(expr.NewAssigned("A", expr.NewExact[any](3)))
+		require.NoError(t, err)
+		require.InDelta(t, 10, est, 5)
+
+		est, _, err = analyzed.Estimate(expr.NewAssigned("A", expr.NewRange[any](nil, nil)))
+		require.NoError(t, err)
+		require.Equal(t, float64(100), est)
+	})
+
+	t.Run("Tracking", func(t *testing.T) {
+		stats, err := tracked.Stats()
+		require.NoError(t, err)
+		require.Equal(t, int64(100), stats.Entries)
+		require.InEpsilon(t, 10, float64(stats.Distinct[0]), 0.1)
+
+		require.NoError(t, ins.Delete([]byte{0}))
+		require.NoError(t, ins.Set([]byte{1}, &TestStruct{A: 11, B: "1"}))
+
+		stats, err = tracked.Stats()
+		require.NoError(t, err)
+		require.Equal(t, int64(99), stats.Entries)
+		require.InEpsilon(t, 11, float64(stats.Distinct[0]), 0.1)
+	})
+
+	t.Run("Layout", func(t *testing.T) {
+		// The refs are kept at the root of the extension stores and the statistics apart from them.
+		for _, name := range []string{"analyzed", "tracked"} {
+			_, err := txn.Get([]byte("m" + name + "\x00sstats"))
+			require.NoError(t, err, name)
+		}
+
+		iter := txn.NewIterator(badger.IteratorOptions{Prefix: []byte("x")})
+		defer iter.Close()
+		var n int
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			n++
+		}
+		require.Equal(t, 2*99, n)
+	})
+}
+
+type countingStore struct {
+	badgerutils.BadgerStore
+	counter *writeCounter
+}
+
+func (s countingStore) Set(key, value []byte) error {
+	s.counter.sets++
+	return s.BadgerStore.Set(key, value)
+}
+
+func (s countingStore) SetEntry(e *badger.Entry) error {
+	s.counter.sets++
+	return s.BadgerStore.SetEntry(e)
+}
+
+func (s countingStore) Delete(key []byte) error {
+	s.counter.deletes++
+	return s.BadgerStore.Delete(key)
+}
+
+type writeCounter struct {
+	sets, deletes int
+}
+
+func (c *writeCounter) Instantiate(txn *badger.Txn) badgerutils.BadgerStore {
+	return countingStore{BadgerStore: txn, counter: c}
+}
+
+func TestExtensionInstance_OnSetDiff(t *testing.T) {
+	tagsIndexer := tagsIndexer{}
+	aIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[TestStruct](false),
+		&lex.Encoder{},
+		concat.NewComponent("A").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		indexer     indexing.Indexer[TestStruct]
+		old, new    *TestStruct
+		oldOpts     []any
+		newOpts     []any
+		wantSets    int
+		wantDeletes int
+	}{
+		{
+			name:     "Unchanged",
+			indexer:  tagsIndexer,
+			old:      &TestStruct{A: 1, B: "a,b,c"},
+			new:      &TestStruct{A: 2, B: "a,b,c"},
+			wantSets: 0,
+		},
+		{
+			name:        "Partially changed",
+			indexer:     tagsIndexer,
+			old:         &TestStruct{B: "a,b,c"},
+			new:         &TestStruct{B: "a,c,d,e"},
+			wantSets:    2,
+			wantDeletes: 1,
+		},
+		{
+			name:     "Change detector",
+			indexer:  aIndexer,
+			old:      &TestStruct{A: 1, B: "foo"},
+			new:      &TestStruct{A: 1, B: "bar"},
+			wantSets: 0,
+		},
+		{
+			name:        "Change detector changed",
+			indexer:     aIndexer,
+			old:         &TestStruct{A: 1, B: "foo"},
+			new:         &TestStruct{A: 2, B: "foo"},
+			wantSets:    1,
+			wantDeletes: 1,
+		},
+		{
+			name:     "Unchanged expiry",
+			indexer:  tagsIndexer,
+			old:      &TestStruct{B: "a,b,c"},
+			new:      &TestStruct{B: "a,b,c"},
+			oldOpts:  []any{badgerutils.WithExpiresAt(math.MaxUint32)},
+			newOpts:  []any{badgerutils.WithExpiresAt(math.MaxUint32)},
+			wantSets: 0,
+		},
+		{
+			name:     "Changed expiry",
+			indexer:  tagsIndexer,
+			old:      &TestStruct{B: "a,b,c"},
+			new:      &TestStruct{B: "a,b,c"},
+			oldOpts:  []any{badgerutils.WithExpiresAt(math.MaxUint32)},
+			wantSets: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &writeCounter{}
+			store := extstore.New[TestStruct](counter).
+				WithExtension("test", indexing.NewExtension(tt.indexer))
+
+			txn := testutil.PrepareTxn(t, true)
+			ins := store.Instantiate(txn)
+			require.NoError(t, ins.SetWithOptions([]byte{1}, tt.old, tt.oldOpts...))
+
+			*counter = writeCounter{}
+			require.NoError(t, ins.SetWithOptions([]byte{1}, tt.new, tt.newOpts...))
+			// The data itself is always written once.
+			require.Equal(t, tt.wantSets+1, counter.sets)
+			require.Equal(t, tt.wantDeletes, counter.deletes)
+
+			it, err := ins.GetExtension("test").(*indexing.ExtensionInstance[TestStruct]).
+				Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("A", expr.NewRange[any](nil, nil)))
+			require.NoError(t, err)
+			defer it.Close()
+			got, err := iters.Collect(it)
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{{1}}, got)
+		})
+	}
+}
+
+// tagsIndexer indexes each of the comma separated values of B.
+type tagsIndexer struct{}
+
+func (tagsIndexer) Index(v *TestStruct, _ bool) ([]badgerutils.RawKVPair, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var kvs []badgerutils.RawKVPair
+	for _, tag := range strings.Split(v.B, ",") {
+		kvs = append(kvs, badgerutils.NewRawKVPair([]byte(tag), nil))
+	}
+	return kvs, nil
+}
+
+func (tagsIndexer) Lookup(args ...any) (badgerutils.Iterator[[]byte, indexing.Chunk], error) {
+	if len(args) == 1 {
+		if a, ok := args[0].(expr.Assigned); ok {
+			if e, ok := a.Expression().(expr.Exact[any]); ok {
+				tag := []byte(e.Value().(string))
+				return iters.Slice([]indexing.Chunk{indexing.NewChunk(expr.NewBound(tag, false), expr.NewBound(tag, false))}), nil
+			}
+		}
+	}
+
+	return iters.Slice([]indexing.Chunk{indexing.NewChunk(nil, nil)}), nil
+}
+
+func TestExtensionInstance_LookupDistinct(t *testing.T) {
+	aIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[TestStruct](false),
+		&lex.Encoder{},
+		concat.NewComponent("A").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	store := extstore.New[TestStruct](pstore.New(nil, []byte("prefix"))).
+		WithExtension("a", indexing.NewExtension(aIndexer)).
+		WithExtension("tags", indexing.NewExtension(tagsIndexer{}))
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+	for i := 0; i < 6; i++ {
+		require.NoError(t, ins.Set([]byte{byte(i)}, &TestStruct{A: i % 3, B: "a,b"}))
+	}
+
+	lookup := func(t *testing.T, name string, opts badger.IteratorOptions, args ...any) [][]byte {
+		it, err := ins.GetExtension(name).(*indexing.ExtensionInstance[TestStruct]).Lookup(opts, args...)
+		require.NoError(t, err)
+		defer it.Close()
+
+		keys, err := iters.Collect(it)
+		require.NoError(t, err)
+		return keys
+	}
+	reverse := badger.DefaultIteratorOptions
+	reverse.Reverse = true
+
+	t.Run("Duplicate set values", func(t *testing.T) {
+		arg := expr.NewAssigned("A", expr.NewSet[any](2, 1, 2))
+		require.Equal(t, [][]byte{{1}, {4}, {2}, {5}}, lookup(t, "a", badger.DefaultIteratorOptions, arg))
+		require.Equal(t, [][]byte{{5}, {2}, {4}, {1}}, lookup(t, "a", reverse, arg))
+	})
+
+	t.Run("Multiple refs", func(t *testing.T) {
+		require.Equal(t, [][]byte{{0}, {1}, {2}, {3}, {4}, {5}}, lookup(t, "tags", badger.DefaultIteratorOptions))
+		require.Equal(t, [][]byte{{5}, {4}, {3}, {2}, {1}, {0}}, lookup(t, "tags", reverse))
+	})
+}
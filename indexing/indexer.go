@@ -1,9 +1,16 @@
 package indexing
 
 import (
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
+	refstore "github.com/ehsanranjbar/badgerutils/store/ref"
 )
 
+// ErrUnsupportedQuery is returned by the lookups of an indexer when the index can't serve the given arguments.
+var ErrUnsupportedQuery = errors.New("unsupported query")
+
 // Indexer is an indexer.
 type Indexer[T any] interface {
 	Index(v *T, set bool) ([]badgerutils.RawKVPair, error)
@@ -15,3 +22,9 @@ type IndexDescriptor interface {
 	SupportedQueries() []string
 	SupportedValues() []string
 }
+
+// Searcher is an optional interface for indexers whose lookups can't be described as a union of chunks,
+// e.g. when the candidates of several chunks must be intersected. The returned iterator yields the keys.
+type Searcher interface {
+	Search(store *refstore.Instance, opts badger.IteratorOptions, args ...any) (badgerutils.Iterator[[]byte, []byte], error)
+}
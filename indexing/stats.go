@@ -0,0 +1,311 @@
+package indexing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/internal/hll"
+	refstore "github.com/ehsanranjbar/badgerutils/store/ref"
+	msgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// DefaultHistogramBuckets is the default number of buckets of the histogram of an index.
+	DefaultHistogramBuckets = 32
+
+	sketchPrecision = 10
+)
+
+var (
+	// ErrNoStats is returned when the statistics of an index are not available yet.
+	ErrNoStats = errors.New("index statistics are not available")
+	// ErrNoEstimate is returned when the index can't estimate the number of entries matching a lookup.
+	ErrNoEstimate = errors.New("index doesn't support estimation")
+
+	statsKey = []byte("stats")
+)
+
+// ComponentSplitter is an optional interface for indexers whose keys are concatenation of several components.
+// It's used to estimate the number of distinct values of each leading components of the index.
+type ComponentSplitter interface {
+	SplitKey(key []byte) ([][]byte, error)
+}
+
+// Stats holds the statistics of an index.
+type Stats struct {
+	// Entries is the total number of entries in the index.
+	Entries int64
+	// Distinct holds the estimated number of distinct values of the leading components of the index keys,
+	// i.e. Distinct[i] is the estimated number of distinct values of the first i+1 components.
+	// Indexers that don't implement ComponentSplitter are treated as a single component.
+	Distinct []uint64
+	// Histogram is an equi-depth histogram over the index keys.
+	Histogram []Bucket
+}
+
+// Bucket is a bucket of an equi-depth histogram which holds the number of index entries in [Lower, Upper].
+type Bucket struct {
+	Lower    []byte `msgpack:"l"`
+	Upper    []byte `msgpack:"u"`
+	Count    int64  `msgpack:"c"`
+	Distinct int64  `msgpack:"d"`
+}
+
+// Estimate returns the estimated number of index entries that fall in any of the given chunks.
+func (s *Stats) Estimate(chunks ...Chunk) float64 {
+	var n float64
+	for _, c := range chunks {
+		for _, b := range s.Histogram {
+			n += b.estimate(c)
+		}
+	}
+
+	return min(n, float64(s.Entries))
+}
+
+// Selectivity returns the estimated fraction of index entries that fall in any of the given chunks.
+func (s *Stats) Selectivity(chunks ...Chunk) float64 {
+	if s.Entries == 0 {
+		return 0
+	}
+
+	return s.Estimate(chunks...) / float64(s.Entries)
+}
+
+func (b Bucket) estimate(c Chunk) float64 {
+	if b.Count <= 0 || !c.Overlaps(ChunkComparator, NewChunk(expr.NewBound(b.Lower, false), expr.NewBound(b.Upper, false))) {
+		return 0
+	}
+
+	if c.Contains(ChunkComparator, b.Lower) && c.Contains(ChunkComparator, b.Upper) {
+		return float64(b.Count)
+	}
+
+	if !c.Low().IsEmpty() && !c.High().IsEmpty() && bytes.Equal(c.Low().Value(), c.High().Value()) {
+		// Point lookups are assumed to hit one of the distinct keys of the bucket.
+		return float64(b.Count) / float64(max(b.Distinct, 1))
+	}
+
+	return float64(b.Count) / 2
+}
+
+// statsRecord is the persisted form of the statistics.
+type statsRecord struct {
+	Entries   int64    `msgpack:"e"`
+	Sketches  [][]byte `msgpack:"s"`
+	Histogram []Bucket `msgpack:"h"`
+}
+
+func (r *statsRecord) stats() (*Stats, error) {
+	s := &Stats{
+		Entries:   r.Entries,
+		Distinct:  make([]uint64, 0, len(r.Sketches)),
+		Histogram: r.Histogram,
+	}
+	for _, bz := range r.Sketches {
+		sk, err := hll.FromBytes(bz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode sketch: %w", err)
+		}
+		s.Distinct = append(s.Distinct, sk.Estimate())
+	}
+
+	return s, nil
+}
+
+// add adds the given index key to the sketches and the histogram of the record.
+func (r *statsRecord) add(leading [][]byte) error {
+	r.Entries++
+
+	for len(r.Sketches) < len(leading) {
+		r.Sketches = append(r.Sketches, hll.New(sketchPrecision).Bytes())
+	}
+	for i, p := range leading {
+		sk, err := hll.FromBytes(r.Sketches[i])
+		if err != nil {
+			return fmt.Errorf("failed to decode sketch: %w", err)
+		}
+		sk.Add(p)
+	}
+
+	key := leading[len(leading)-1]
+	if len(r.Histogram) == 0 {
+		r.Histogram = append(r.Histogram, Bucket{
+			Lower:    bytes.Clone(key),
+			Upper:    bytes.Clone(key),
+			Count:    1,
+			Distinct: 1,
+		})
+		return nil
+	}
+
+	// Buckets are only widened here, they will be rebalanced by the next analysis.
+	b := &r.Histogram[r.bucketOf(key)]
+	switch {
+	case bytes.Compare(key, b.Lower) < 0:
+		b.Lower = bytes.Clone(key)
+		b.Distinct++
+	case bytes.Compare(key, b.Upper) > 0:
+		b.Upper = bytes.Clone(key)
+		b.Distinct++
+	}
+	b.Count++
+
+	return nil
+}
+
+// remove removes the given index key from the entries count and the histogram of the record.
+// Sketches can't forget the keys, so the distinct estimations stay an upper bound until the next analysis.
+func (r *statsRecord) remove(key []byte) {
+	if r.Entries > 0 {
+		r.Entries--
+	}
+
+	if len(r.Histogram) == 0 {
+		return
+	}
+
+	b := &r.Histogram[r.bucketOf(key)]
+	if b.Count > 0 {
+		b.Count--
+	}
+}
+
+// bucketOf returns the bucket that key falls in or the closest bucket before it.
+func (r *statsRecord) bucketOf(key []byte) int {
+	i := sort.Search(len(r.Histogram), func(i int) bool {
+		return bytes.Compare(r.Histogram[i].Upper, key) >= 0
+	})
+	if i == len(r.Histogram) {
+		return i - 1
+	}
+	if i > 0 && bytes.Compare(key, r.Histogram[i].Lower) < 0 {
+		return i - 1
+	}
+
+	return i
+}
+
+// leadingPrefixes returns the concatenation of each leading components of the key.
+func leadingPrefixes(splitter ComponentSplitter, key []byte) ([][]byte, error) {
+	if splitter == nil {
+		return [][]byte{key}, nil
+	}
+
+	comps, err := splitter.SplitKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split key: %w", err)
+	}
+	if len(comps) == 0 {
+		return [][]byte{key}, nil
+	}
+
+	prefixes := make([][]byte, 0, len(comps))
+	var n int
+	for _, c := range comps {
+		n += len(c)
+		prefixes = append(prefixes, key[:n])
+	}
+	return prefixes, nil
+}
+
+func loadStats(store badgerutils.BadgerStore) (*statsRecord, error) {
+	item, err := store.Get(statsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var r statsRecord
+	err = item.Value(func(val []byte) error {
+		return msgpack.Unmarshal(val, &r)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stats: %w", err)
+	}
+	return &r, nil
+}
+
+func saveStats(store badgerutils.BadgerStore, r *statsRecord) error {
+	bz, err := msgpack.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %w", err)
+	}
+
+	return store.Set(statsKey, bz)
+}
+
+// analyze scans the whole index and computes its statistics.
+func analyze(refs *refstore.Instance, splitter ComponentSplitter, buckets int) (*statsRecord, error) {
+	var total int64
+	err := scanRefs(refs, func(_ []byte) error {
+		total++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &statsRecord{}
+	if total == 0 {
+		return r, nil
+	}
+
+	depth := (total + int64(buckets) - 1) / int64(buckets)
+	var cur *Bucket
+	err = scanRefs(refs, func(key []byte) error {
+		leading, err := leadingPrefixes(splitter, key)
+		if err != nil {
+			return err
+		}
+
+		r.Entries++
+		for len(r.Sketches) < len(leading) {
+			r.Sketches = append(r.Sketches, hll.New(sketchPrecision).Bytes())
+		}
+		for i, p := range leading {
+			sk, _ := hll.FromBytes(r.Sketches[i])
+			sk.Add(p)
+		}
+
+		switch {
+		case cur != nil && bytes.Equal(cur.Upper, key):
+			// Equal keys are kept in the same bucket.
+			cur.Count++
+		case cur != nil && cur.Count < depth:
+			cur.Upper = bytes.Clone(key)
+			cur.Count++
+			cur.Distinct++
+		default:
+			r.Histogram = append(r.Histogram, Bucket{
+				Lower:    bytes.Clone(key),
+				Upper:    bytes.Clone(key),
+				Count:    1,
+				Distinct: 1,
+			})
+			cur = &r.Histogram[len(r.Histogram)-1]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func scanRefs(refs *refstore.Instance, fn func(key []byte) error) error {
+	iter := refs.NewIterator(badger.IteratorOptions{PrefetchValues: false})
+	defer iter.Close()
+
+	for iter.Rewind(); iter.Valid(); iter.Next() {
+		if err := fn(iter.Key()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
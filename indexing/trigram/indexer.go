@@ -0,0 +1,257 @@
+package trigram
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"slices"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/ehsanranjbar/badgerutils/schema"
+	refstore "github.com/ehsanranjbar/badgerutils/store/ref"
+)
+
+// ErrUnselective is returned when a pattern doesn't contain any trigram to narrow down the candidates.
+var ErrUnselective = fmt.Errorf("%w: pattern is not selective enough for trigram index", indexing.ErrUnsupportedQuery)
+
+// Indexer is an indexer that indexes the character trigrams of a string field for LIKE and regexp queries.
+type Indexer[T any] struct {
+	extractor schema.PathExtractor[T]
+	path      string
+}
+
+// New creates a new trigram indexer on the given path.
+func New[T any](extractor schema.PathExtractor[T], path string) *Indexer[T] {
+	if extractor == nil {
+		panic("extractor is required")
+	}
+
+	return &Indexer[T]{
+		extractor: extractor,
+		path:      path,
+	}
+}
+
+// Index implements the indexing.Indexer interface.
+func (idx *Indexer[T]) Index(v *T, set bool) ([]badgerutils.RawKVPair, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	ev, err := idx.extractor.ExtractPath(*v, idx.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract path %s: %w", idx.path, err)
+	}
+
+	strs, err := collectStrings(ev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index %s: %w", idx.path, err)
+	}
+
+	var tris []string
+	for _, s := range strs {
+		tris = append(tris, Trigrams(s)...)
+	}
+	tris = dedup(tris)
+
+	pairs := make([]badgerutils.RawKVPair, 0, len(tris))
+	for _, t := range tris {
+		pairs = append(pairs, badgerutils.NewRawKVPair([]byte(t), nil))
+	}
+	return pairs, nil
+}
+
+func collectStrings(v any) ([]string, error) {
+	rv, ok := v.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(v)
+	}
+	rv = reflect.Indirect(rv)
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.String:
+		return []string{rv.String()}, nil
+	case reflect.Array, reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return []string{string(rv.Bytes())}, nil
+		}
+
+		var strs []string
+		for i := 0; i < rv.Len(); i++ {
+			s, err := collectStrings(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, s...)
+		}
+		return strs, nil
+	case reflect.Interface:
+		return collectStrings(rv.Elem())
+	default:
+		return nil, fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
+
+// Changed implements the indexing.ChangeDetector interface.
+func (idx *Indexer[T]) Changed(old, new *T) (bool, error) {
+	return indexing.PathsChanged(idx.extractor, old, new, idx.path)
+}
+
+// Fingerprint implements the indexing.Fingerprinter interface.
+func (idx *Indexer[T]) Fingerprint() []byte {
+	h := sha256.Sum256([]byte("trigram/v1;" + idx.path))
+	return h[:]
+}
+
+// Lookup implements the indexing.Indexer interface.
+// Trigram lookups can't be described as a union of chunks so Search should be used instead.
+func (idx *Indexer[T]) Lookup(args ...any) (badgerutils.Iterator[[]byte, indexing.Chunk], error) {
+	return nil, fmt.Errorf("trigram indexer doesn't support chunk lookups, use Search instead")
+}
+
+// Search implements the indexing.Searcher interface.
+// It evaluates the trigram query of the given pattern and returns the keys of the candidates
+// that should be verified with the actual predicate.
+func (idx *Indexer[T]) Search(
+	store *refstore.Instance,
+	opts badger.IteratorOptions,
+	args ...any,
+) (badgerutils.Iterator[[]byte, []byte], error) {
+	q, err := idx.query(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lookup arguments: %w", err)
+	}
+	if q.Op == QAll {
+		return nil, ErrUnselective
+	}
+
+	keys, err := idx.eval(store, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]byte, 0, len(keys))
+	for k := range keys {
+		result = append(result, []byte(k))
+	}
+	slices.SortFunc(result, bytes.Compare)
+	if opts.Reverse {
+		slices.Reverse(result)
+	}
+
+	return iters.Slice(result), nil
+}
+
+func (idx *Indexer[T]) query(args []any) (*Query, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: expected exactly one argument, got %d", indexing.ErrUnsupportedQuery, len(args))
+	}
+
+	e, ok := args[0].(expr.Assigned)
+	if !ok {
+		return nil, fmt.Errorf("%w: argument type %T", indexing.ErrUnsupportedQuery, args[0])
+	}
+	if e.Name() != idx.path {
+		return nil, fmt.Errorf("%w: path %s", indexing.ErrUnsupportedQuery, e.Name())
+	}
+
+	switch ex := e.Expression().(type) {
+	case expr.Like:
+		return LikeQuery(ex.Pattern()), nil
+	case expr.Regexp:
+		return RegexpQuery(ex.Pattern())
+	default:
+		return nil, fmt.Errorf("%w: expression type %T", indexing.ErrUnsupportedQuery, ex)
+	}
+}
+
+func (idx *Indexer[T]) eval(store *refstore.Instance, q *Query) (map[string]struct{}, error) {
+	var (
+		result map[string]struct{}
+		merge  func(map[string]struct{})
+	)
+	switch q.Op {
+	case QAnd:
+		merge = func(s map[string]struct{}) {
+			if result == nil {
+				result = s
+				return
+			}
+			for k := range result {
+				if _, ok := s[k]; !ok {
+					delete(result, k)
+				}
+			}
+		}
+	case QOr:
+		result = make(map[string]struct{})
+		merge = func(s map[string]struct{}) {
+			for k := range s {
+				result[k] = struct{}{}
+			}
+		}
+	default:
+		return nil, ErrUnselective
+	}
+
+	for _, t := range q.Trigrams {
+		s, err := scanTrigram(store, t)
+		if err != nil {
+			return nil, err
+		}
+		merge(s)
+		if q.Op == QAnd && len(result) == 0 {
+			return result, nil
+		}
+	}
+	for _, sub := range q.Subs {
+		s, err := idx.eval(store, sub)
+		if err != nil {
+			return nil, err
+		}
+		merge(s)
+		if q.Op == QAnd && len(result) == 0 {
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+func scanTrigram(store *refstore.Instance, t string) (map[string]struct{}, error) {
+	tb := []byte(t)
+	iter := indexing.LookupChunks(
+		store,
+		iters.Slice([]indexing.Chunk{indexing.NewChunk(expr.NewBound(tb, false), expr.NewBound(tb, false))}),
+		badger.IteratorOptions{PrefetchValues: false},
+	)
+	defer iter.Close()
+
+	keys := make(map[string]struct{})
+	for iter.Rewind(); iter.Valid(); iter.Next() {
+		k, err := iter.Value()
+		if err != nil {
+			return nil, err
+		}
+		keys[string(k)] = struct{}{}
+	}
+
+	return keys, nil
+}
+
+// SupportedQueries implements the indexing.IndexDescriptor interface.
+func (idx *Indexer[T]) SupportedQueries() []string {
+	return []string{fmt.Sprintf("queryable(%s, 'like,regexp')", idx.path)}
+}
+
+// SupportedValues implements the indexing.IndexDescriptor interface.
+func (idx *Indexer[T]) SupportedValues() []string {
+	return nil
+}
@@ -0,0 +1,153 @@
+package trigram_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+	"github.com/ehsanranjbar/badgerutils/indexing/trigram"
+	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/ehsanranjbar/badgerutils/schema"
+	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	"github.com/ehsanranjbar/badgerutils/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type TestStruct struct {
+	Name string
+	Tags []string
+}
+
+func (t TestStruct) MarshalBinary() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (t *TestStruct) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, t)
+}
+
+func TestIndexer_Index(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		input   *TestStruct
+		want    []badgerutils.RawKVPair
+		wantErr bool
+	}{
+		{
+			name:  "String",
+			path:  "Name",
+			input: &TestStruct{Name: "hello"},
+			want: []badgerutils.RawKVPair{
+				badgerutils.NewRawKVPair([]byte("ell"), nil),
+				badgerutils.NewRawKVPair([]byte("hel"), nil),
+				badgerutils.NewRawKVPair([]byte("llo"), nil),
+			},
+		},
+		{
+			name:  "Short string",
+			path:  "Name",
+			input: &TestStruct{Name: "hi"},
+			want:  []badgerutils.RawKVPair{},
+		},
+		{
+			name:  "Slice",
+			path:  "Tags",
+			input: &TestStruct{Tags: []string{"abcd", "bcd"}},
+			want: []badgerutils.RawKVPair{
+				badgerutils.NewRawKVPair([]byte("abc"), nil),
+				badgerutils.NewRawKVPair([]byte("bcd"), nil),
+			},
+		},
+		{
+			name:  "Nil input",
+			path:  "Name",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:    "Non-existing field",
+			path:    "NonExisting",
+			input:   &TestStruct{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := trigram.New(schema.NewReflectPathExtractor[TestStruct](false), tt.path)
+
+			got, err := idx.Index(tt.input, true)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIndexer_Search(t *testing.T) {
+	store := extstore.New[TestStruct](nil).
+		WithExtension("name", indexing.NewExtension(trigram.New(schema.NewReflectPathExtractor[TestStruct](false), "Name")))
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+	extIns := ins.GetExtension("name").(*indexing.ExtensionInstance[TestStruct])
+
+	values := map[byte]*TestStruct{
+		1: {Name: "foobar"},
+		2: {Name: "barbaz"},
+		3: {Name: "bazfoo"},
+		4: {Name: "qux"},
+	}
+	for k, v := range values {
+		require.NoError(t, ins.Set([]byte{k}, v))
+	}
+
+	search := func(t *testing.T, e any) [][]byte {
+		iter, err := extIns.Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("Name", e))
+		require.NoError(t, err)
+		defer iter.Close()
+
+		keys, err := iters.Collect(iter)
+		require.NoError(t, err)
+		return keys
+	}
+
+	t.Run("Like", func(t *testing.T) {
+		require.Equal(t, [][]byte{{1}, {3}}, search(t, expr.NewLike("%foo%")))
+		require.Equal(t, [][]byte{{2}}, search(t, expr.NewLike("%barbaz%")))
+		require.Empty(t, search(t, expr.NewLike("%nothing%")))
+	})
+
+	t.Run("Regexp", func(t *testing.T) {
+		require.Equal(t, [][]byte{{1}, {2}, {3}}, search(t, expr.NewRegexp("(foo|baz)")))
+		require.Equal(t, [][]byte{{1}}, search(t, expr.NewRegexp("foo.*bar")))
+	})
+
+	t.Run("Unselective", func(t *testing.T) {
+		_, err := extIns.Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("Name", expr.NewLike("%fo%")))
+		require.ErrorIs(t, err, trigram.ErrUnselective)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := extIns.Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("Name", expr.NewExact[any]("foo")))
+		require.ErrorIs(t, err, indexing.ErrUnsupportedQuery)
+		_, err = extIns.Lookup(badger.DefaultIteratorOptions, expr.NewAssigned("Other", expr.NewLike("%foo%")))
+		require.ErrorIs(t, err, indexing.ErrUnsupportedQuery)
+	})
+
+	t.Run("DeleteSharedTrigrams", func(t *testing.T) {
+		require.NoError(t, ins.Delete([]byte{1}))
+		require.Equal(t, [][]byte{{3}}, search(t, expr.NewLike("%foo%")))
+
+		require.NoError(t, ins.Set([]byte{3}, &TestStruct{Name: "quxbar"}))
+		require.Empty(t, search(t, expr.NewLike("%foo%")))
+		require.Equal(t, [][]byte{{2}, {3}}, search(t, expr.NewLike("%bar%")))
+	})
+}
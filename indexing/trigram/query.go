@@ -0,0 +1,219 @@
+package trigram
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryOp is the operator of a trigram query.
+type QueryOp int
+
+const (
+	// QAll matches everything, meaning that the query can't be narrowed down by the index.
+	QAll QueryOp = iota
+	// QAnd matches when all of the trigrams and sub queries match.
+	QAnd
+	// QOr matches when any of the trigrams or sub queries match.
+	QOr
+)
+
+// Query is a boolean query over trigrams.
+type Query struct {
+	Op       QueryOp
+	Trigrams []string
+	Subs     []*Query
+}
+
+var allQuery = &Query{Op: QAll}
+
+// String returns the string representation of the query.
+func (q *Query) String() string {
+	switch q.Op {
+	case QAll:
+		return "+"
+	case QAnd, QOr:
+		sep := " "
+		if q.Op == QOr {
+			sep = " | "
+		}
+		parts := make([]string, 0, len(q.Trigrams)+len(q.Subs))
+		for _, t := range q.Trigrams {
+			parts = append(parts, fmt.Sprintf("%q", t))
+		}
+		for _, s := range q.Subs {
+			parts = append(parts, "("+s.String()+")")
+		}
+		return strings.Join(parts, sep)
+	default:
+		return "?"
+	}
+}
+
+// and returns the conjunction of two queries.
+func (q *Query) and(r *Query) *Query {
+	if q.Op == QAll {
+		return r
+	}
+	if r.Op == QAll {
+		return q
+	}
+
+	res := &Query{Op: QAnd}
+	for _, x := range []*Query{q, r} {
+		if x.Op == QAnd {
+			res.Trigrams = append(res.Trigrams, x.Trigrams...)
+			res.Subs = append(res.Subs, x.Subs...)
+		} else {
+			res.Subs = append(res.Subs, x)
+		}
+	}
+	res.Trigrams = dedup(res.Trigrams)
+	return res
+}
+
+// or returns the disjunction of two queries.
+func (q *Query) or(r *Query) *Query {
+	if q.Op == QAll || r.Op == QAll {
+		return allQuery
+	}
+
+	res := &Query{Op: QOr}
+	for _, x := range []*Query{q, r} {
+		if x.Op == QOr {
+			res.Trigrams = append(res.Trigrams, x.Trigrams...)
+			res.Subs = append(res.Subs, x.Subs...)
+		} else if x.Op == QAnd && len(x.Trigrams) == 1 && len(x.Subs) == 0 {
+			res.Trigrams = append(res.Trigrams, x.Trigrams[0])
+		} else {
+			res.Subs = append(res.Subs, x)
+		}
+	}
+	res.Trigrams = dedup(res.Trigrams)
+	return res
+}
+
+func dedup(s []string) []string {
+	slices.Sort(s)
+	return slices.Compact(s)
+}
+
+// Trigrams returns the distinct trigrams of the given string in sorted order.
+func Trigrams(s string) []string {
+	if utf8.RuneCountInString(s) < 3 {
+		return nil
+	}
+
+	var (
+		tris []string
+		offs []int
+	)
+	for i := range s {
+		offs = append(offs, i)
+	}
+	offs = append(offs, len(s))
+	for i := 0; i+3 < len(offs); i++ {
+		tris = append(tris, s[offs[i]:offs[i+3]])
+	}
+
+	return dedup(tris)
+}
+
+// literalQuery returns a query that matches the strings containing the given literal.
+func literalQuery(lit string) *Query {
+	tris := Trigrams(lit)
+	if len(tris) == 0 {
+		return allQuery
+	}
+
+	return &Query{Op: QAnd, Trigrams: tris}
+}
+
+// LikeQuery returns the trigram query that every string matching the given like pattern satisfies.
+func LikeQuery(pattern string) *Query {
+	q := allQuery
+	var (
+		lit     strings.Builder
+		escaped bool
+		inClass bool
+	)
+	flush := func() {
+		q = q.and(literalQuery(lit.String()))
+		lit.Reset()
+	}
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			lit.WriteRune(r)
+			escaped = false
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+		case r == '\\':
+			escaped = true
+		case r == '%', r == '*', r == '_', r == '?':
+			flush()
+		case r == '[':
+			flush()
+			inClass = true
+		default:
+			lit.WriteRune(r)
+		}
+	}
+	flush()
+
+	return q
+}
+
+// RegexpQuery returns the trigram query that every string matching the given regular expression satisfies.
+func RegexpQuery(pattern string) (*Query, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse regexp: %w", err)
+	}
+
+	return regexpQuery(re.Simplify()), nil
+}
+
+func regexpQuery(re *syntax.Regexp) *Query {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return allQuery
+		}
+		return literalQuery(string(re.Rune))
+	case syntax.OpCapture:
+		return regexpQuery(re.Sub[0])
+	case syntax.OpPlus:
+		return regexpQuery(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min == 0 {
+			return allQuery
+		}
+		return regexpQuery(re.Sub[0])
+	case syntax.OpConcat:
+		q := allQuery
+		var lit strings.Builder
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && sub.Flags&syntax.FoldCase == 0 {
+				lit.WriteString(string(sub.Rune))
+				continue
+			}
+			q = q.and(literalQuery(lit.String()))
+			lit.Reset()
+			q = q.and(regexpQuery(sub))
+		}
+		return q.and(literalQuery(lit.String()))
+	case syntax.OpAlternate:
+		q := regexpQuery(re.Sub[0])
+		for _, sub := range re.Sub[1:] {
+			q = q.or(regexpQuery(sub))
+		}
+		return q
+	default:
+		return allQuery
+	}
+}
@@ -0,0 +1,76 @@
+package trigram_test
+
+import (
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/indexing/trigram"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"ab", nil},
+		{"abc", []string{"abc"}},
+		{"abcd", []string{"abc", "bcd"}},
+		{"aaaa", []string{"aaa"}},
+		{"héllo", []string{"hél", "llo", "éll"}},
+	}
+
+	for _, test := range tests {
+		result := trigram.Trigrams(test.input)
+		require.Equal(t, test.expected, result, "Trigrams(%q)", test.input)
+	}
+}
+
+func TestLikeQuery(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"%foo%", `"foo"`},
+		{"%fo%", "+"},
+		{"foobar%", `"bar" "foo" "oba" "oob"`},
+		{"%foo%bar", `"bar" "foo"`},
+		{"foo_bar", `"bar" "foo"`},
+		{"ba*", "+"},
+		{"f[ao]obar", `"bar" "oba"`},
+		{`10\%abc`, `"%ab" "0%a" "10%" "abc"`},
+	}
+
+	for _, test := range tests {
+		result := trigram.LikeQuery(test.pattern)
+		require.Equal(t, test.expected, result.String(), "LikeQuery(%q)", test.pattern)
+	}
+}
+
+func TestRegexpQuery(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+		wantErr  bool
+	}{
+		{pattern: "foo", expected: `"foo"`},
+		{pattern: "foo.*bar", expected: `"bar" "foo"`},
+		{pattern: "(foo|bar)baz", expected: `"baz" ("bar" | "foo")`},
+		{pattern: "foo|ba", expected: "+"},
+		{pattern: "(?i)foo", expected: "+"},
+		{pattern: "a+bcd", expected: `"bcd"`},
+		{pattern: "(abc)+", expected: `"abc"`},
+		{pattern: "(abc)?", expected: "+"},
+		{pattern: "(", wantErr: true},
+	}
+
+	for _, test := range tests {
+		result, err := trigram.RegexpQuery(test.pattern)
+		if test.wantErr {
+			require.Error(t, err, "RegexpQuery(%q)", test.pattern)
+			continue
+		}
+		require.NoError(t, err, "RegexpQuery(%q)", test.pattern)
+		require.Equal(t, test.expected, result.String(), "RegexpQuery(%q)", test.pattern)
+	}
+}
@@ -1,7 +1,9 @@
 package indexing
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"strings"
 
 	"github.com/ehsanranjbar/badgerutils"
 	"github.com/ehsanranjbar/badgerutils/schema"
@@ -59,6 +61,42 @@ func (i *ValueInjector[T]) Index(v *T, set bool) ([]badgerutils.RawKVPair, error
 	return idxs, nil
 }
 
+// Changed implements the ChangeDetector interface. Changes are only detected if both the indexer and
+// the retriever implement ChangeDetector, otherwise it reports a change.
+func (i *ValueInjector[T]) Changed(old, new *T) (bool, error) {
+	icd, ok := i.indexer.(ChangeDetector[T])
+	if !ok {
+		return true, nil
+	}
+	rcd, ok := i.retriever.(ChangeDetector[T])
+	if !ok {
+		return true, nil
+	}
+
+	changed, err := icd.Changed(old, new)
+	if err != nil || changed {
+		return changed, err
+	}
+	return rcd.Changed(old, new)
+}
+
+// Fingerprint implements the Fingerprinter interface. It returns nil if the underlying indexer isn't a Fingerprinter.
+func (i *ValueInjector[T]) Fingerprint() []byte {
+	fp, ok := i.indexer.(Fingerprinter)
+	if !ok {
+		return nil
+	}
+	ifp := fp.Fingerprint()
+	if ifp == nil {
+		return nil
+	}
+
+	h := sha256.New()
+	h.Write(ifp)
+	fmt.Fprintf(h, ";%T;%s", i.retriever, strings.Join(i.retriever.Paths(), ","))
+	return h.Sum(nil)
+}
+
 // Lookup implements the Indexer interface.
 func (i *ValueInjector[T]) Lookup(args ...any) (badgerutils.Iterator[[]byte, Chunk], error) {
 	return i.indexer.Lookup(args...)
@@ -129,6 +167,11 @@ func (r *MapValueRetriever[T]) RetrieveValue(v *T) ([]byte, error) {
 	return b, nil
 }
 
+// Changed implements the ChangeDetector interface.
+func (r *MapValueRetriever[T]) Changed(old, new *T) (bool, error) {
+	return PathsChanged(r.extractor, old, new, r.paths...)
+}
+
 // Paths implements the ValueRetriever interface.
 func (r *MapValueRetriever[T]) Paths() []string {
 	return r.paths
@@ -0,0 +1,105 @@
+package hll
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Sketch is a HyperLogLog sketch that estimates the number of distinct elements added to it.
+type Sketch struct {
+	p         uint8
+	registers []uint8
+}
+
+// New creates a new sketch with the given precision which determines the number of registers as 2^p.
+func New(p uint8) *Sketch {
+	if p < 4 || p > 16 {
+		panic("precision must be between 4 and 16")
+	}
+
+	return &Sketch{
+		p:         p,
+		registers: make([]uint8, 1<<p),
+	}
+}
+
+// FromBytes restores a sketch from its registers.
+func FromBytes(bz []byte) (*Sketch, error) {
+	p := bits.Len(uint(len(bz))) - 1
+	if p < 4 || p > 16 || len(bz) != 1<<p {
+		return nil, fmt.Errorf("invalid sketch size %d", len(bz))
+	}
+
+	return &Sketch{
+		p:         uint8(p),
+		registers: bz,
+	}, nil
+}
+
+// Bytes returns the registers of the sketch.
+func (s *Sketch) Bytes() []byte {
+	return s.registers
+}
+
+// Add adds the given element to the sketch.
+func (s *Sketch) Add(bz []byte) {
+	h := xxhash.Sum64(bz)
+	i := h >> (64 - s.p)
+	rank := uint8(bits.LeadingZeros64(h<<s.p|1<<(s.p-1))) + 1
+	if rank > s.registers[i] {
+		s.registers[i] = rank
+	}
+}
+
+// Merge merges the other sketch with the same precision into this one.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.p != other.p {
+		return fmt.Errorf("precision mismatch %d != %d", s.p, other.p)
+	}
+
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the estimated number of distinct elements added to the sketch.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(len(s.registers))
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	e := alpha(m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// Small range correction using linear counting.
+		e = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(e + 0.5)
+}
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/m)
+	}
+}
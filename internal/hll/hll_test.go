@@ -0,0 +1,59 @@
+package hll_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/internal/hll"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSketch(t *testing.T) {
+	tests := []struct {
+		name     string
+		distinct int
+		repeats  int
+	}{
+		{name: "Empty", distinct: 0, repeats: 1},
+		{name: "Small", distinct: 10, repeats: 3},
+		{name: "Medium", distinct: 1000, repeats: 2},
+		{name: "Large", distinct: 100000, repeats: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := hll.New(12)
+			for r := 0; r < tt.repeats; r++ {
+				for i := 0; i < tt.distinct; i++ {
+					s.Add(binary.BigEndian.AppendUint64(nil, uint64(i)))
+				}
+			}
+
+			require.InEpsilon(t, float64(tt.distinct)+1, float64(s.Estimate())+1, 0.05)
+		})
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	a, b := hll.New(10), hll.New(10)
+	for i := 0; i < 1000; i++ {
+		a.Add(binary.BigEndian.AppendUint64(nil, uint64(i)))
+		b.Add(binary.BigEndian.AppendUint64(nil, uint64(i+500)))
+	}
+
+	require.NoError(t, a.Merge(b))
+	require.InEpsilon(t, 1500, float64(a.Estimate()), 0.1)
+	require.Error(t, a.Merge(hll.New(12)))
+}
+
+func TestFromBytes(t *testing.T) {
+	s := hll.New(8)
+	s.Add([]byte("foo"))
+
+	restored, err := hll.FromBytes(s.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, s.Estimate(), restored.Estimate())
+
+	_, err = hll.FromBytes(make([]byte, 100))
+	require.Error(t, err)
+}
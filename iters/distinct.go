@@ -0,0 +1,86 @@
+package iters
+
+import (
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+)
+
+// DistinctIterator is an iterator that skips the items whose identity is already seen.
+// It keeps the identities of all the seen items in memory until it's rewound or seeked.
+type DistinctIterator[K, V any] struct {
+	base badgerutils.Iterator[K, V]
+	id   func(K, V) string
+	seen map[string]struct{}
+}
+
+// Distinct creates a new distinct iterator that identifies the items with the given function.
+func Distinct[K, V any](base badgerutils.Iterator[K, V], id func(K, V) string) *DistinctIterator[K, V] {
+	return &DistinctIterator[K, V]{base: base, id: id}
+}
+
+// DistinctValues creates a new distinct iterator that identifies the items by their values.
+func DistinctValues[K any](base badgerutils.Iterator[K, []byte]) *DistinctIterator[K, []byte] {
+	return Distinct(base, func(_ K, v []byte) string { return string(v) })
+}
+
+// Close implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Close() {
+	it.base.Close()
+}
+
+// Item implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Item() *badger.Item {
+	return it.base.Item()
+}
+
+// Next implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Next() {
+	it.base.Next()
+	it.skip()
+}
+
+// Rewind implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Rewind() {
+	it.seen = make(map[string]struct{})
+	it.base.Rewind()
+	it.skip()
+}
+
+// Seek implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Seek(key []byte) {
+	it.seen = make(map[string]struct{})
+	it.base.Seek(key)
+	it.skip()
+}
+
+func (it *DistinctIterator[K, V]) skip() {
+	for it.base.Valid() {
+		v, err := it.base.Value()
+		if err != nil {
+			// Leaving the error to be returned by Value.
+			return
+		}
+
+		id := it.id(it.base.Key(), v)
+		if _, ok := it.seen[id]; !ok {
+			it.seen[id] = struct{}{}
+			return
+		}
+		it.base.Next()
+	}
+}
+
+// Valid implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Valid() bool {
+	return it.base.Valid()
+}
+
+// Key implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Key() K {
+	return it.base.Key()
+}
+
+// Value implements the Iterator interface.
+func (it *DistinctIterator[K, V]) Value() (value V, err error) {
+	return it.base.Value()
+}
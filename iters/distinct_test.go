@@ -0,0 +1,22 @@
+package iters_test
+
+import (
+	"testing"
+
+	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistinct(t *testing.T) {
+	iter := iters.DistinctValues(iters.Slice([][]byte{{1}, {2}, {1}, {3}, {2}, {2}}))
+	defer iter.Close()
+
+	actual, err := iters.Collect(iter)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1}, {2}, {3}}, actual)
+
+	// Rewinding forgets the seen items.
+	actual, err = iters.Collect(iter)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1}, {2}, {3}}, actual)
+}
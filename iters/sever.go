@@ -42,6 +42,11 @@ func (it *SeverIterator[K, V]) Next() {
 }
 
 func (it *SeverIterator[K, V]) checkSevered() {
+	if !it.base.Valid() {
+		it.severed = false
+		return
+	}
+
 	value, err := it.base.Value()
 	if err != nil || it.pred(it.base.Key(), value, it.base.Item()) {
 		it.severed = true
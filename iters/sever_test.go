@@ -32,3 +32,19 @@ func TestSever(t *testing.T) {
 	require.Error(t, err)
 	require.Zero(t, value)
 }
+
+func TestSever_Exhausted(t *testing.T) {
+	it := iters.Sever(iters.Slice([]int{}), func(_ []byte, _ int, _ *badger.Item) bool {
+		return false
+	})
+	defer it.Close()
+
+	it.Rewind()
+	require.False(t, it.Valid())
+
+	it = iters.Sever(iters.Slice([]int{1, 2}), func(_ []byte, _ int, _ *badger.Item) bool {
+		return false
+	})
+	it.Seek(binary.BigEndian.AppendUint64(nil, 5))
+	require.False(t, it.Valid())
+}
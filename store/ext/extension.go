@@ -3,6 +3,7 @@ package ext
 import (
 	"context"
 
+	"github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
 )
 
@@ -18,13 +19,69 @@ type StoreRegistry interface {
 	RegisterStore(badgerutils.Instantiator[badgerutils.BadgerStore])
 }
 
+// MetaStoreRegistry determines if an extension needs a private store for its metadata, e.g. statistics, that is
+// kept apart from the store of StoreRegistry so that it can't collide with the keys the extension writes there.
+type MetaStoreRegistry interface {
+	RegisterMetaStore(badgerutils.Instantiator[badgerutils.BadgerStore])
+}
+
+// Verifier is an optional interface for extensions that need to check their persisted state against their
+// current definition before being used, e.g. to detect that the definition of an index has changed.
+// Verify returns an error if the state doesn't match the definition, see Store.Verify.
+type Verifier interface {
+	Verify(txn *badger.Txn) error
+}
+
+// Rebuilder is an optional interface for extensions whose persisted state can be rebuilt from the values
+// of the store, see Rebuild. NeedsRebuild reports whether the state must be rebuilt, Drop deletes at most n
+// entries of the state and returns the number of deleted entries and Rebuilt is called once all the values
+// are set again to the extension.
+type Rebuilder interface {
+	NeedsRebuild(txn *badger.Txn) (bool, error)
+	Drop(txn *badger.Txn, n int) (int, error)
+	Rebuilt(txn *badger.Txn) error
+}
+
 // ExtensionInstance is an instance of an extension.
 // Both OnDelete and OnSet are called before the actual operation is done.
+// If the value is set with a TTL or its previous version had one, opts of OnSet contain an Expiry
+// so that the entries written by the extension expire along with the value.
 type ExtensionInstance[T any] interface {
 	OnDelete(ctx context.Context, key []byte, value *T) error
 	OnSet(ctx context.Context, key []byte, old, new *T, opts ...any) error
 }
 
+// Expiry is the expiry of a value passed to the extensions as an option of OnSet.
+type Expiry struct {
+	// ExpiresAt is the expiry of the new value, zero means that it never expires.
+	ExpiresAt uint64
+	// Previous is the expiry of the previous version of the value, zero if it never expired or didn't exist.
+	Previous uint64
+}
+
+// Changed reports whether the expiry differs from the one of the previous version, in which case the entries
+// written for the previous version must be rewritten with the new expiry.
+func (e Expiry) Changed() bool {
+	return e.ExpiresAt != e.Previous
+}
+
+// FindExpiry returns the Expiry among the given options of OnSet if any.
+func FindExpiry(opts []any) (Expiry, bool) {
+	for _, opt := range opts {
+		if e, ok := opt.(Expiry); ok {
+			return e, true
+		}
+	}
+	return Expiry{}, false
+}
+
+// BulkLoader is an optional interface for extensions that can generate the entries they write on OnSet of
+// a new value without a transaction, so that the values can be loaded in bulk, e.g. by badger.WriteBatch.
+// opts are the same as the ones passed to OnSet.
+type BulkLoader[T any] interface {
+	BulkEntries(key []byte, v *T, opts ...any) ([]*badger.Entry, error)
+}
+
 // ExtOption is an option that is specific to an extension.
 type ExtOption struct {
 	extName string
@@ -0,0 +1,145 @@
+package ext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+	sstore "github.com/ehsanranjbar/badgerutils/store/serialized"
+)
+
+// Rebuild rebuilds the persisted state of the extensions that implement Rebuilder and need to be rebuilt,
+// e.g. the indexes whose definition has changed. The state of the extensions is dropped and then every value
+// of the store is set again to them through OnSet with a nil old value.
+// At most batchSize entries are dropped or values are set in each transaction and the number of values
+// that are set again is returned. The extensions are only marked as rebuilt at the end so Rebuild can be
+// safely run again if it fails in the middle.
+// The database must not be opened in managed mode (see badger.OpenManaged) as badger.DB.Update panics on it.
+func Rebuild[T any, PT sstore.Pointer[T]](
+	db *badger.DB,
+	s *Store[T, PT],
+	batchSize int,
+) (int, error) {
+	if batchSize <= 0 {
+		panic("batch size must be positive")
+	}
+
+	var names []string
+	err := db.View(func(txn *badger.Txn) error {
+		for name, ext := range s.exts.Iter() {
+			r, ok := ext.(Rebuilder)
+			if !ok {
+				continue
+			}
+
+			ok, err := r.NeedsRebuild(txn)
+			if err != nil {
+				return fmt.Errorf("failed to check extension %s: %w", name, err)
+			}
+			if ok {
+				names = append(names, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild extensions: %w", err)
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	for _, name := range names {
+		r := s.GetExtension(name).(Rebuilder)
+		for {
+			var n int
+			err := db.Update(func(txn *badger.Txn) error {
+				var err error
+				n, err = r.Drop(txn, batchSize)
+				return err
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to drop extension %s: %w", name, err)
+			}
+			if n < batchSize {
+				break
+			}
+		}
+	}
+
+	var (
+		total int
+		last  []byte
+	)
+	for {
+		var n int
+		err := db.Update(func(txn *badger.Txn) error {
+			var err error
+			n, last, err = reindexBatch(s.Instantiate(txn), names, last, batchSize)
+			return err
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to rebuild extensions: %w", err)
+		}
+
+		total += n
+		if n < batchSize {
+			break
+		}
+	}
+
+	err = db.Update(func(txn *badger.Txn) error {
+		for _, name := range names {
+			if err := s.GetExtension(name).(Rebuilder).Rebuilt(txn); err != nil {
+				return fmt.Errorf("failed to mark extension %s as rebuilt: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+// reindexBatch sets at most n values after the key last to the extensions with the given names
+// and returns the last key that is set.
+func reindexBatch[T any, PT sstore.Pointer[T]](
+	ins *Instance[T, PT],
+	names []string,
+	last []byte,
+	n int,
+) (int, []byte, error) {
+	iter := ins.NewIterator(badger.DefaultIteratorOptions)
+	defer iter.Close()
+
+	ctx := context.Background()
+	var count int
+	for iter.Seek(last); iter.Valid() && count < n; iter.Next() {
+		key := iter.Key()
+		if last != nil && bytes.Equal(key, last) {
+			continue
+		}
+
+		v, err := iter.Value()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to decode value: %w", err)
+		}
+		var opts []any
+		if exp := iter.Item().ExpiresAt(); exp != 0 {
+			opts = append(opts, Expiry{ExpiresAt: exp})
+		}
+		for _, name := range names {
+			if err := ins.GetExtension(name).OnSet(ctx, key, nil, v, opts...); err != nil {
+				return 0, nil, fmt.Errorf("failure in running extension %s OnSet: %w", name, err)
+			}
+		}
+
+		last = bytes.Clone(key)
+		count++
+	}
+
+	return count, last, nil
+}
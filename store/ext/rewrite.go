@@ -0,0 +1,192 @@
+package ext
+
+import (
+	"bytes"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	sstore "github.com/ehsanranjbar/badgerutils/store/serialized"
+)
+
+// RewriteOptions are the options of Rotate and Migrate.
+type RewriteOptions[T any] struct {
+	hook func(key []byte, v *T) error
+}
+
+// WithRewriteHook sets a function that is called with the key and the decoded value of every value before
+// it's set again, e.g. to restore the fields that are not serialized as part of the value.
+func WithRewriteHook[T any](fn func(key []byte, v *T) error) func(*RewriteOptions[T]) {
+	return func(o *RewriteOptions[T]) {
+		o.hook = fn
+	}
+}
+
+// Rotate re-encrypts the values of the store that are not encrypted with the current key of kp
+// by setting them again through Instance.Set, so the extensions like indexes stay consistent.
+// The store must use a codec.EncryptedCodec as its outermost codec with the same key provider.
+// At most batchSize values are re-encrypted in each transaction and the number of re-encrypted values is returned.
+// Rotate can be safely run again if it fails in the middle.
+// The database must not be opened in managed mode (see badger.OpenManaged) as badger.DB.Update panics on it.
+func Rotate[T any, PT sstore.Pointer[T]](
+	db *badger.DB,
+	s *Store[T, PT],
+	kp codec.KeyProvider,
+	batchSize int,
+	opts ...func(*RewriteOptions[T]),
+) (int, error) {
+	if batchSize <= 0 {
+		panic("batch size must be positive")
+	}
+
+	id, _, err := kp.CurrentKey()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current key: %w", err)
+	}
+
+	n, err := rewrite(db, s, func(val []byte) (bool, error) {
+		kid, ok := codec.EncryptionKeyId(val)
+		return !ok || kid != id, nil
+	}, batchSize, opts)
+	if err != nil {
+		return n, fmt.Errorf("failed to rotate keys: %w", err)
+	}
+
+	return n, nil
+}
+
+// Migrate rewrites the values of the store that are written in an older format of its codec
+// (see codec.Upgradable) through Instance.Set, so the extensions like indexes stay consistent.
+// At most batchSize values are rewritten in each transaction and the number of rewritten values is returned.
+// Migrate can be safely run again if it fails in the middle.
+// Since the extensions only see the upgraded values, the definition of the indexes on the fields
+// changed by the upgraders should be changed as well so that they're rebuilt.
+// Like Rotate, it doesn't support databases opened in managed mode.
+func Migrate[T any, PT sstore.Pointer[T]](
+	db *badger.DB,
+	s *Store[T, PT],
+	batchSize int,
+	opts ...func(*RewriteOptions[T]),
+) (int, error) {
+	if batchSize <= 0 {
+		panic("batch size must be positive")
+	}
+
+	u, ok := s.codec.(codec.Upgradable)
+	if !ok {
+		return 0, nil
+	}
+
+	n, err := rewrite(db, s, u.IsStale, batchSize, opts)
+	if err != nil {
+		return n, fmt.Errorf("failed to migrate values: %w", err)
+	}
+
+	return n, nil
+}
+
+// rewrite sets again the values of the store for which stale returns true in batches of batchSize.
+func rewrite[T any, PT sstore.Pointer[T]](
+	db *badger.DB,
+	s *Store[T, PT],
+	stale func(val []byte) (bool, error),
+	batchSize int,
+	opts []func(*RewriteOptions[T]),
+) (int, error) {
+	var o RewriteOptions[T]
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var (
+		total int
+		last  []byte
+	)
+	for {
+		var n int
+		err := db.Update(func(txn *badger.Txn) error {
+			var err error
+			n, last, err = rewriteBatch(s.Instantiate(txn), stale, o.hook, last, batchSize)
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += n
+		if n < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// rewriteBatch sets again at most n stale values after the key last and returns the last rewritten key.
+func rewriteBatch[T any, PT sstore.Pointer[T]](
+	ins *Instance[T, PT],
+	stale func(val []byte) (bool, error),
+	hook func(key []byte, v *T) error,
+	last []byte,
+	n int,
+) (int, []byte, error) {
+	var (
+		keys   [][]byte
+		opts   [][]any
+		values []*T
+	)
+	iter := ins.NewIterator(badger.DefaultIteratorOptions)
+	for iter.Seek(last); iter.Valid() && len(keys) < n; iter.Next() {
+		key := iter.Key()
+		if last != nil && bytes.Equal(key, last) {
+			continue
+		}
+
+		var ok bool
+		err := iter.Item().Value(func(val []byte) (err error) {
+			ok, err = stale(val)
+			return err
+		})
+		if err != nil {
+			iter.Close()
+			return 0, nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		v, err := iter.Value()
+		if err != nil {
+			iter.Close()
+			return 0, nil, fmt.Errorf("failed to decode value: %w", err)
+		}
+		if hook != nil {
+			if err := hook(key, v); err != nil {
+				iter.Close()
+				return 0, nil, err
+			}
+		}
+		keys = append(keys, bytes.Clone(key))
+		opts = append(opts, entryOptions(iter.Item()))
+		values = append(values, v)
+	}
+	iter.Close()
+
+	for i, key := range keys {
+		if err := ins.SetWithOptions(key, values[i], opts[i]...); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if len(keys) == 0 {
+		return 0, last, nil
+	}
+	return len(keys), keys[len(keys)-1], nil
+}
+
+// entryOptions returns the options that keep the expiry and the user metadata of the item when it's rewritten.
+func entryOptions(item *badger.Item) []any {
+	return []any{
+		badgerutils.WithExpiresAt(item.ExpiresAt()),
+		badgerutils.WithMeta(item.UserMeta()),
+	}
+}
@@ -0,0 +1,64 @@
+package ext_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotate(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	keys := codec.NewKeyRing()
+	require.NoError(t, keys.Add(1, bytes.Repeat([]byte{1}, 32)))
+
+	// The existing plaintext values are encrypted by the rotation.
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		bz, _ := json.Marshal(TestStruct{A: 1, B: "foo"})
+		return txn.Set([]byte{'d', 1}, bz)
+	}))
+
+	store := extstore.NewWithCodec(nil, codec.Encrypted(codec.JSON[*TestStruct](), keys).AllowPlaintext())
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		for i := byte(2); i <= 4; i++ {
+			if err := ins.Set([]byte{i}, &TestStruct{A: int(i)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	n, err := extstore.Rotate(db, store, keys, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.NoError(t, keys.Add(2, bytes.Repeat([]byte{2}, 32)))
+	n, err = extstore.Rotate(db, store, keys, 1)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte{'d', 1})
+		require.NoError(t, err)
+		bz, err := item.ValueCopy(nil)
+		require.NoError(t, err)
+		id, ok := codec.EncryptionKeyId(bz)
+		require.True(t, ok)
+		require.Equal(t, uint32(2), id)
+
+		v, err := store.Instantiate(txn).Get([]byte{1})
+		require.NoError(t, err)
+		require.Equal(t, &TestStruct{A: 1, B: "foo"}, v)
+		return nil
+	}))
+
+	require.Panics(t, func() { extstore.Rotate(db, store, keys, 0) })
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
 	"github.com/ehsanranjbar/badgerutils/internal/ordmap"
 	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
 	sstore "github.com/ehsanranjbar/badgerutils/store/serialized"
@@ -16,16 +18,19 @@ import (
 var (
 	dataStorePrefix = []byte{'d'}
 	extStorePrefix  = []byte{'x'}
+	metaStorePrefix = []byte{'m'}
 )
 
 // Store is a wrapper around a serialized store with an ordered list of extensions
 // that can modify data before it is stored or do arbitrary operations on set and delete.
 type Store[
 	T any,
-	PT sstore.BSP[T],
+	PT sstore.Pointer[T],
 ] struct {
-	dataStore   badgerutils.Instantiator[badgerutils.StoreInstance[[]byte, *T, *T, badgerutils.Iterator[[]byte, *T]]]
+	dataStore   *sstore.Store[T, *T]
+	codec       codec.Codec[*T]
 	extStore    *pstore.Store
+	metaStore   *pstore.Store
 	exts        *ordmap.Map[string, Extension[T]]
 	prefix      []byte
 	initialized bool
@@ -37,14 +42,35 @@ func New[
 	T any,
 	PT sstore.BSP[T],
 ](base badgerutils.Instantiator[badgerutils.BadgerStore]) *Store[T, PT] {
+	return newStore[T, PT](base, sstore.BinaryCodec[T, PT]())
+}
+
+// NewWithCodec creates a new Store that serializes values using the given codec.
+func NewWithCodec[T any](
+	base badgerutils.Instantiator[badgerutils.BadgerStore],
+	c codec.Codec[*T],
+) *Store[T, *T] {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+
+	return newStore[T, *T](base, c)
+}
+
+func newStore[T any, PT sstore.Pointer[T]](
+	base badgerutils.Instantiator[badgerutils.BadgerStore],
+	c codec.Codec[*T],
+) *Store[T, PT] {
 	var prefix []byte
 	if pfx, ok := base.(prefixed); ok {
 		prefix = pfx.Prefix()
 	}
 
 	store := &Store[T, PT]{
-		dataStore: sstore.New[T, PT](pstore.New(base, dataStorePrefix)),
+		dataStore: sstore.NewWithCodec(pstore.New(base, dataStorePrefix), c),
+		codec:     c,
 		extStore:  pstore.New(base, extStorePrefix),
+		metaStore: pstore.New(base, metaStorePrefix),
 		exts:      ordmap.New[string, Extension[T]](),
 		prefix:    prefix,
 	}
@@ -65,6 +91,13 @@ func (s *Store[T, PT]) WithExtension(name string, ext Extension[T]) *Store[T, PT
 	if sr, ok := ext.(StoreRegistry); ok {
 		sr.RegisterStore(pstore.New(s.extStore, []byte(name)))
 	}
+	if mr, ok := ext.(MetaStoreRegistry); ok {
+		if strings.IndexByte(name, 0) >= 0 {
+			panic("extension name must not contain null bytes")
+		}
+		// The terminator keeps the metadata of an extension apart from the ones whose names it prefixes.
+		mr.RegisterMetaStore(pstore.New(s.metaStore, append([]byte(name), 0)))
+	}
 
 	err := s.exts.Add(name, ext)
 	if err != nil {
@@ -81,11 +114,39 @@ func (s *Store[T, PT]) Instantiate(txn *badger.Txn) *Instance[T, PT] {
 		s.initialized = true
 	})
 
-	return &Instance[T, PT]{
+	ins := &Instance[T, PT]{
 		dataStore: s.dataStore.Instantiate(txn),
 		exts:      s.instantiateExts(txn),
 		prefix:    s.prefix,
 	}
+
+	return ins
+}
+
+// Verify checks the persisted state of the extensions that implement Verifier against their definitions
+// and returns the errors of the ones that don't match, e.g. indexing.ErrDefinitionMismatch.
+// It should be called before the store is used and after Rebuild if some of the extensions need to be rebuilt.
+// The database must not be opened in managed mode (see badger.OpenManaged) as badger.DB.Update panics on it.
+func (s *Store[T, PT]) Verify(db *badger.DB) error {
+	var errs []error
+	err := db.Update(func(txn *badger.Txn) error {
+		for name, ext := range s.exts.Iter() {
+			v, ok := ext.(Verifier)
+			if !ok {
+				continue
+			}
+
+			if err := v.Verify(txn); err != nil {
+				errs = append(errs, fmt.Errorf("failed to verify extension %s: %w", name, err))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify extensions: %w", err)
+	}
+
+	return errors.Join(errs...)
 }
 
 func (s *Store[T, PT]) instantiateExts(txn *badger.Txn) *ordmap.Map[string, ExtensionInstance[T]] {
@@ -106,6 +167,44 @@ func (s *Store[T, PT]) GetExtension(name string) Extension[T] {
 	return nil
 }
 
+// Codec returns the codec used to serialize values.
+func (s *Store[T, PT]) Codec() codec.Codec[*T] {
+	return s.codec
+}
+
+// BulkEntries returns the entry of the new value with the given key and the entries that the extensions write
+// for it, so that it can be written without a transaction, e.g. by badger.WriteBatch. opts are handled the same
+// as SetWithOptions. As the existing value of the key is not looked up, it must only be used for keys that
+// don't exist. All the extensions must implement BulkLoader.
+func (s *Store[T, PT]) BulkEntries(key []byte, v *T, opts ...any) ([]*badger.Entry, error) {
+	expiresAt, entryOpts := findEntryOptions(opts)
+
+	var entries []*badger.Entry
+	for name, ext := range s.exts.Iter() {
+		bl, ok := ext.(BulkLoader[T])
+		if !ok {
+			return nil, fmt.Errorf("extension %s doesn't support bulk loading", name)
+		}
+
+		extOpts := filterOptions(name, opts)
+		if expiresAt != 0 {
+			extOpts = append(extOpts, Expiry{ExpiresAt: expiresAt})
+		}
+		es, err := bl.BulkEntries(key, v, extOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failure in running extension %s BulkEntries: %w", name, err)
+		}
+		entries = append(entries, es...)
+	}
+
+	e, err := s.dataStore.Entry(key, v, entryOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	return append(entries, e), nil
+}
+
 func (s *Store[T, PT]) Prefix() []byte {
 	return s.prefix
 }
@@ -113,9 +212,9 @@ func (s *Store[T, PT]) Prefix() []byte {
 // Instance is an instance of Store.
 type Instance[
 	T any,
-	PT sstore.BSP[T],
+	PT sstore.Pointer[T],
 ] struct {
-	dataStore badgerutils.StoreInstance[[]byte, *T, *T, badgerutils.Iterator[[]byte, *T]]
+	dataStore *sstore.Instance[T, *T]
 	exts      *ordmap.Map[string, ExtensionInstance[T]]
 	prefix    []byte
 }
@@ -166,24 +265,47 @@ func (s *Instance[T, PT]) Get(key []byte) (*T, error) {
 	return s.dataStore.Get(key)
 }
 
+// GetWithItem is similar to Get, but it also returns the badger.Item of the value.
+func (s *Instance[T, PT]) GetWithItem(key []byte) (*badger.Item, *T, error) {
+	return s.dataStore.GetWithItem(key)
+}
+
+// GetItem returns the badger.Item of the key without decoding its value, so it's never written back.
+func (s *Instance[T, PT]) GetItem(key []byte) (*badger.Item, error) {
+	return s.dataStore.GetItem(key)
+}
+
+// Decode decodes the value of an item returned by GetItem without writing it back.
+func (s *Instance[T, PT]) Decode(item *badger.Item) (*T, error) {
+	return s.dataStore.Decode(item)
+}
+
 // NewIterator implements the badgerutils.StoreInstance interface.
 func (s *Instance[T, PT]) NewIterator(opts badger.IteratorOptions) badgerutils.Iterator[[]byte, *T] {
 	return s.dataStore.NewIterator(opts)
 }
 
+// History returns an iterator over the versions of the value of the key from the newest to the oldest.
+func (s *Instance[T, PT]) History(key []byte) *sstore.HistoryIterator[T] {
+	return s.dataStore.History(key)
+}
+
 // Set implements the badgerutils.StoreInstance interface.
 func (s *Instance[T, PT]) Set(key []byte, v *T) error {
 	return s.SetWithOptions(key, v)
 }
 
-// SetWithOptions is a variant of Set that allows passing options to extensions.
+// SetWithOptions is a variant of Set that allows passing options to extensions
+// and badgerutils.EntryOption values for the entry of the value, e.g. a TTL.
 func (s *Instance[T, PT]) SetWithOptions(key []byte, v *T, opts ...any) error {
-	err := s.onSet(key, v, opts...)
+	expiresAt, entryOpts := findEntryOptions(opts)
+
+	err := s.onSet(key, v, expiresAt, opts...)
 	if err != nil {
 		return err
 	}
 
-	err = s.dataStore.Set(key, v)
+	err = s.dataStore.SetWithOptions(key, v, entryOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to set record: %w", err)
 	}
@@ -191,19 +313,29 @@ func (s *Instance[T, PT]) SetWithOptions(key []byte, v *T, opts ...any) error {
 	return nil
 }
 
-func (s *Instance[T, PT]) onSet(key []byte, new *T, opts ...any) error {
+func (s *Instance[T, PT]) onSet(key []byte, new *T, expiresAt uint64, opts ...any) error {
 	if s.exts.Len() == 0 {
 		return nil
 	}
 
-	old, err := s.dataStore.Get(key)
+	item, old, err := s.dataStore.GetWithItem(key)
 	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
 		return fmt.Errorf("failed to get record: %w", err)
 	}
 
+	// The expiry is passed even if it's zero so that the entries written for a previous version
+	// with a TTL don't expire before the value.
+	exp := Expiry{ExpiresAt: expiresAt}
+	if item != nil {
+		exp.Previous = item.ExpiresAt()
+	}
+
 	ctx := context.Background()
 	for name, ext := range s.exts.Iter() {
 		extOpts := filterOptions(name, opts)
+		if exp != (Expiry{}) {
+			extOpts = append(extOpts, exp)
+		}
 		err := ext.OnSet(ctx, key, old, new, extOpts...)
 		if err != nil {
 			return fmt.Errorf("failure in running extension %s OnSet: %w", name, err)
@@ -236,3 +368,12 @@ func (s *Instance[T, PT]) GetExtension(name string) ExtensionInstance[T] {
 
 	return nil
 }
+
+// findEntryOptions returns the expiry of the value and the options of its entry among the given arbitrary options.
+func findEntryOptions(opts []any) (uint64, []badgerutils.EntryOption) {
+	eo, ok := badgerutils.FindEntryOptions(opts)
+	if !ok {
+		return 0, nil
+	}
+	return eo.ExpiresAt, []badgerutils.EntryOption{badgerutils.WithEntryOptions(eo)}
+}
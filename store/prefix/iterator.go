@@ -1,14 +1,20 @@
 package prefix
 
 import (
+	"bytes"
+	"slices"
+
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
 )
 
 // Iterator is an iterator trims the prefix from the key.
 type Iterator struct {
-	base   badgerutils.BadgerIterator
-	prefix []byte
+	base    badgerutils.BadgerIterator
+	prefix  []byte
+	reverse bool
+	bound   []byte
+	upper   []byte
 }
 
 // NewIterator creates a new iterator.
@@ -19,6 +25,16 @@ func NewIterator(base badgerutils.BadgerIterator, prefix []byte) *Iterator {
 	}
 }
 
+// WithOptions makes the iterator to follow the options that its base iterator is created with by Instance.NewIterator.
+// A reverse iterator is positioned on the last key with the prefix and opts.Prefix on rewinding and seeking to
+// an empty key instead of before them, and it checks the prefixes itself as its base iterator is not limited to them.
+func (it *Iterator) WithOptions(opts badger.IteratorOptions) *Iterator {
+	it.reverse = opts.Reverse
+	it.bound = slices.Concat(it.prefix, opts.Prefix)
+	it.upper = nil
+	return it
+}
+
 // NewIteratorFromStore creates a new iterator from a prefix store.
 func NewIteratorFromStore(store badgerutils.BadgerStore) *Iterator {
 	var prefix []byte
@@ -46,17 +62,77 @@ func (it *Iterator) Next() {
 
 // Rewind rewinds the iterator.
 func (it *Iterator) Rewind() {
+	if it.reverse && len(it.prefix) > 0 {
+		it.seekLast()
+		return
+	}
+
 	it.base.Rewind()
 }
 
 // Seek seeks the key.
 func (it *Iterator) Seek(key []byte) {
-	it.base.Seek(append(it.prefix, key...))
+	if it.reverse && len(key) == 0 && len(it.prefix) > 0 {
+		it.seekLast()
+		return
+	}
+
+	it.base.Seek(slices.Concat(it.prefix, key))
+}
+
+// seekLast positions a reverse iterator on the last key with the prefix. Reverse seeks land on the greatest key
+// less than or equal to the given key, so it seeks to the smallest key that is greater than all the keys with
+// the prefix and skips it if it exists. The base iterator covers that key too, see reverseBound.
+func (it *Iterator) seekLast() {
+	if it.upper == nil {
+		it.upper = upperBound(it.bound)
+	}
+	if len(it.upper) == 0 {
+		// No key is greater than all the keys with a prefix of only 0xff bytes, so the last key is the last one
+		// of the base iterator.
+		it.base.Rewind()
+		return
+	}
+
+	it.base.Seek(it.upper)
+	if it.base.Valid() && bytes.Equal(it.base.Item().Key(), it.upper) {
+		it.base.Next()
+	}
+}
+
+// upperBound increments the last byte of the prefix that is not 0xff and truncates the bytes after it,
+// which is the smallest key that is greater than all the keys with the prefix. It returns an empty slice
+// if the prefix only consists of 0xff bytes.
+func upperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			upper := slices.Clone(prefix[:i+1])
+			upper[i]++
+			return upper
+		}
+	}
+	return []byte{}
+}
+
+// reverseBound returns the longest prefix of the given prefix that also covers its upper bound, i.e. the bytes
+// before its last byte that is not 0xff, so that a reverse iterator can be positioned by seeking to the upper bound.
+func reverseBound(prefix []byte) []byte {
+	i := len(prefix) - 1
+	for i >= 0 && prefix[i] == 0xff {
+		i--
+	}
+	return prefix[:max(i, 0)]
 }
 
 // Valid returns if the iterator is valid.
 func (it *Iterator) Valid() bool {
-	return it.base.Valid()
+	if !it.base.Valid() {
+		return false
+	}
+	if it.reverse {
+		return bytes.HasPrefix(it.base.Item().Key(), it.bound)
+	}
+	return true
 }
 
 // Key returns the current key.
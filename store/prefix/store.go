@@ -1,6 +1,8 @@
 package prefix
 
 import (
+	"slices"
+
 	badger "github.com/dgraph-io/badger/v4"
 	badgerutils "github.com/ehsanranjbar/badgerutils"
 )
@@ -31,7 +33,7 @@ type prefixed interface {
 
 // Prefix returns the prefix of the store.
 func (s *Store) Prefix() []byte {
-	return append(s.basePrefix, s.prefix...)
+	return slices.Concat(s.basePrefix, s.prefix)
 }
 
 // Instantiate creates a new Instance.
@@ -57,33 +59,37 @@ type Instance struct {
 
 // Prefix returns the prefix of the store.
 func (s *Instance) Prefix() []byte {
-	return append(s.basePrefix, s.prefix...)
+	return slices.Concat(s.basePrefix, s.prefix)
 }
 
 // Delete deletes the key from the store.
 func (s *Instance) Delete(key []byte) error {
-	return s.base.Delete(append(s.prefix, key...))
+	return s.base.Delete(slices.Concat(s.prefix, key))
 }
 
 // Get gets the key from the store.
 func (s *Instance) Get(key []byte) (*badger.Item, error) {
-	return s.base.Get(append(s.prefix, key...))
+	return s.base.Get(slices.Concat(s.prefix, key))
 }
 
 // Iterate iterates over the store.
+// Reverse iterators are limited to a shorter prefix that also covers the key right after the ones with the prefix,
+// so they must be wrapped in an Iterator with the same options to be rewound properly and stop at the prefix.
 func (s *Instance) NewIterator(opts badger.IteratorOptions) *badger.Iterator {
-	return s.base.NewIterator(badger.IteratorOptions{
-		Prefix: append(s.prefix, opts.Prefix...),
-	})
+	opts.Prefix = slices.Concat(s.prefix, opts.Prefix)
+	if opts.Reverse {
+		opts.Prefix = reverseBound(opts.Prefix)
+	}
+	return s.base.NewIterator(opts)
 }
 
 // Set sets the key in the store.
 func (s *Instance) Set(key, value []byte) error {
-	return s.base.Set(append(s.prefix, key...), value)
+	return s.base.Set(slices.Concat(s.prefix, key), value)
 }
 
 // SetEntry sets the entry in the store.
 func (s *Instance) SetEntry(e *badger.Entry) error {
-	e.Key = append(s.prefix, e.Key...)
+	e.Key = slices.Concat(s.prefix, e.Key)
 	return s.base.SetEntry(e)
 }
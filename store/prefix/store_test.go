@@ -68,3 +68,80 @@ func TestPrefixStore(t *testing.T) {
 		require.Nil(t, item)
 	})
 }
+
+func TestPrefixStore_SharedPrefix(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A prefix with spare capacity must not be appended into, otherwise the keys of
+	// the writes of the same transaction share the same backing array.
+	pfx := make([]byte, 1, 16)
+	pfx[0] = 'p'
+	store := prefix.New(nil, pfx)
+
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		if err := ins.Set([]byte("a"), []byte("1")); err != nil {
+			return err
+		}
+		return ins.Set([]byte("b"), []byte("2"))
+	}))
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		for key, value := range map[string]string{"pa": "1", "pb": "2"} {
+			item, err := txn.Get([]byte(key))
+			require.NoError(t, err, key)
+			v, err := item.ValueCopy(nil)
+			require.NoError(t, err)
+			require.Equal(t, value, string(v))
+		}
+		return nil
+	}))
+
+	t.Run("Prefix", func(t *testing.T) {
+		parent := prefix.New(nil, pfx)
+		a := prefix.New(parent, []byte("a"))
+		b := prefix.New(parent, []byte("b"))
+		require.Equal(t, []byte("pa"), a.Prefix())
+		require.Equal(t, []byte("pb"), b.Prefix())
+		require.Equal(t, []byte("p"), parent.Prefix())
+	})
+}
+
+func TestIterator_Reverse(t *testing.T) {
+	txn := testutil.PrepareTxn(t, true)
+	for _, key := range []string{"o", "pa", "pb", "p\xff\xff", "q", "qa", "\xffa", "\xff\xff"} {
+		require.NoError(t, txn.Set([]byte(key), nil))
+	}
+
+	tests := []struct {
+		prefix     string
+		optsPrefix string
+		want       []string
+	}{
+		{prefix: "p", want: []string{"\xff\xff", "b", "a"}},
+		{prefix: "p\xff", want: []string{"\xff"}},
+		{prefix: "\xff", want: []string{"\xff", "a"}},
+		{prefix: "r", want: nil},
+		{prefix: "\x00", want: nil},
+		{prefix: "p", optsPrefix: "\xff", want: []string{"\xff\xff"}},
+		{prefix: "q", optsPrefix: "a", want: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix+tt.optsPrefix, func(t *testing.T) {
+			opts := badger.IteratorOptions{Reverse: true, Prefix: []byte(tt.optsPrefix)}
+			ins := prefix.New(nil, []byte(tt.prefix)).Instantiate(txn)
+			iter := prefix.NewIterator(ins.NewIterator(opts), []byte(tt.prefix)).
+				WithOptions(opts)
+			defer iter.Close()
+
+			var got []string
+			for iter.Rewind(); iter.Valid(); iter.Next() {
+				got = append(got, string(iter.Key()))
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package rec
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+)
+
+// analyzeOption is the option returned by WithAnalyze.
+type analyzeOption struct{}
+
+// WithAnalyze is an option of BulkLoad that analyzes the indexes of the store after loading.
+func WithAnalyze() any {
+	return analyzeOption{}
+}
+
+// BulkLoad writes the records to the store using badger.WriteBatch which is much faster than setting them
+// one by one in transactions. The records with a zero id get one from the id function of the store, then they
+// are encoded and the entries of the indexes are generated by calling the indexers directly.
+//
+// BulkLoad is insert-only: as the existing records are not looked up, loading a record whose id already exists
+// overwrites it but leaves the index entries of its old version behind. So it must only be used for new records,
+// e.g. to populate an empty store, with no concurrent writes to them. All the extensions of the store must
+// implement extstore.BulkLoader which the indexes do and they are verified before loading, see Verify.
+// opts are handled the same as Set, e.g. badgerutils.WithTTL.
+// With WithAnalyze among opts, the indexes are analyzed after loading so that their statistics cover
+// the loaded records.
+// The number of loaded records is returned and if it fails in the middle, some of the records may be written.
+// BulkLoad doesn't support databases opened in managed mode (see badger.OpenManaged) as both badger.DB.Update
+// and badger.DB.NewWriteBatch panic on them.
+func (s *Store[I, T, PT]) BulkLoad(db *badger.DB, records iter.Seq[*T], opts ...any) (int, error) {
+	if err := s.Verify(db); err != nil {
+		return 0, err
+	}
+
+	analyze := slices.Contains(opts, any(analyzeOption{}))
+	opts = slices.DeleteFunc(slices.Clone(opts), func(opt any) bool {
+		return opt == any(analyzeOption{})
+	})
+
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+
+	var n int
+	for r := range records {
+		key, err := recordKey[I, T, PT](r, s.idFunc, s.idCodec)
+		if err != nil {
+			return n, err
+		}
+
+		entries, err := s.base.BulkEntries(key, r, opts...)
+		if err != nil {
+			return n, err
+		}
+		for _, e := range entries {
+			if err := wb.SetEntry(e); err != nil {
+				return n, fmt.Errorf("failed to write record: %w", err)
+			}
+		}
+		n++
+	}
+	if err := wb.Flush(); err != nil {
+		return n, fmt.Errorf("failed to flush records: %w", err)
+	}
+
+	if !analyze {
+		return n, nil
+	}
+	for name := range s.indexers {
+		err := db.Update(func(txn *badger.Txn) error {
+			return s.Instantiate(txn).base.GetExtension(name).(*indexing.ExtensionInstance[T]).Analyze()
+		})
+		if err != nil {
+			return n, fmt.Errorf("failed to analyze index %s: %w", name, err)
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,149 @@
+package rec_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/ehsanranjbar/badgerutils/indexing/concat"
+	"github.com/ehsanranjbar/badgerutils/indexing/trigram"
+	"github.com/ehsanranjbar/badgerutils/schema"
+	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
+	"github.com/stretchr/testify/require"
+)
+
+func newBulkStore(t *testing.T) *recstore.Store[int64, plainEntity, *plainEntity] {
+	nameIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[plainEntity](false),
+		&lex.Encoder{},
+		concat.NewComponent("Name").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	var i int64
+	return recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]()).
+		WithIdFunc(func(_ *plainEntity) (int64, error) {
+			i++
+			return i, nil
+		}).
+		WithIndexer("name", nameIndexer).
+		WithIndexer("trgm", trigram.New(schema.NewReflectPathExtractor[plainEntity](false), "Name"))
+}
+
+func TestStore_BulkLoad(t *testing.T) {
+	open := func() *badger.DB {
+		db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	records := func(yield func(*plainEntity) bool) {
+		for i := range 1000 {
+			if !yield(&plainEntity{Name: fmt.Sprintf("name%03d", i%300)}) {
+				return
+			}
+		}
+	}
+
+	// dump returns the records and the refs of the indexes.
+	dump := func(db *badger.DB) [][2][]byte {
+		var kvs [][2][]byte
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			for _, prefix := range []string{"d", "xname", "xtrgm"} {
+				iter := txn.NewIterator(badger.IteratorOptions{Prefix: []byte(prefix)})
+				for iter.Rewind(); iter.Valid(); iter.Next() {
+					v, err := iter.Item().ValueCopy(nil)
+					if err != nil {
+						iter.Close()
+						return err
+					}
+					kvs = append(kvs, [2][]byte{iter.Item().KeyCopy(nil), v})
+				}
+				iter.Close()
+			}
+			return nil
+		}))
+		return kvs
+	}
+
+	bulkDB := open()
+	store := newBulkStore(t)
+	n, err := store.BulkLoad(bulkDB, records)
+	require.NoError(t, err)
+	require.Equal(t, 1000, n)
+
+	setDB := open()
+	setStore := newBulkStore(t)
+	require.NoError(t, setDB.Update(func(txn *badger.Txn) error {
+		ins := setStore.Instantiate(txn)
+		for r := range records {
+			if err := ins.Set(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.Equal(t, dump(setDB), dump(bulkDB))
+
+	require.NoError(t, bulkDB.View(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+
+		iter, err := ins.Query(`Name = "name042"`)
+		require.NoError(t, err)
+		var ids []int64
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			v, err := iter.Value()
+			require.NoError(t, err)
+			require.Equal(t, "name042", v.Name)
+			ids = append(ids, iter.Key())
+		}
+		iter.Close()
+		slices.Sort(ids)
+		require.Equal(t, []int64{43, 343, 643, 943}, ids)
+
+		// The indexes are only analyzed on request.
+		for _, name := range []string{"name", "trgm"} {
+			_, err := txn.Get([]byte("m" + name + "\x00sstats"))
+			require.ErrorIs(t, err, badger.ErrKeyNotFound, name)
+		}
+		return nil
+	}))
+
+	t.Run("Analyze", func(t *testing.T) {
+		db := open()
+		_, err := newBulkStore(t).BulkLoad(db, records, recstore.WithAnalyze())
+		require.NoError(t, err)
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			for _, name := range []string{"name", "trgm"} {
+				_, err := txn.Get([]byte("m" + name + "\x00sstats"))
+				require.NoError(t, err, name)
+			}
+			return nil
+		}))
+	})
+
+	t.Run("UnsupportedExtension", func(t *testing.T) {
+		store := newBulkStore(t).WithExtension("noop", noopExtension{})
+		_, err := store.BulkLoad(open(), records)
+		require.ErrorContains(t, err, "extension noop doesn't support bulk loading")
+	})
+}
+
+type noopExtension struct{}
+
+func (noopExtension) Instantiate(_ *badger.Txn) extstore.ExtensionInstance[plainEntity] {
+	return noopExtension{}
+}
+
+func (noopExtension) OnDelete(_ context.Context, _ []byte, _ *plainEntity) error {
+	return nil
+}
+
+func (noopExtension) OnSet(_ context.Context, _ []byte, _, _ *plainEntity, _ ...any) error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package rec
+
+import (
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+	sstore "github.com/ehsanranjbar/badgerutils/store/serialized"
+)
+
+// HistoryIterator is an iterator over the versions of a record from the newest to the oldest.
+// The keys of the iterator are the commit versions and the values of the versions that deleted
+// the record or are expired are nil.
+type HistoryIterator[I comparable, T any, PT Identifiable[I, T]] struct {
+	*sstore.HistoryIterator[T]
+	id I
+}
+
+// Value returns the current version of the record with its id set or nil if it's deleted or expired at this version.
+func (it *HistoryIterator[I, T, PT]) Value() (*T, error) {
+	v, err := it.HistoryIterator.Value()
+	if err != nil || v == nil {
+		return v, err
+	}
+
+	PT(v).SetId(it.id)
+	return v, nil
+}
+
+// History returns an iterator over the versions of the record with the given id.
+// Only the versions kept by badger are returned, so the database must be opened with
+// badger.Options.NumVersionsToKeep greater than one to have any history.
+func (s *Instance[I, T, PT]) History(id I) (*HistoryIterator[I, T, PT], error) {
+	key, err := s.idCodec.Encode(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode id: %w", err)
+	}
+
+	return &HistoryIterator[I, T, PT]{
+		HistoryIterator: s.base.History(key),
+		id:              id,
+	}, nil
+}
+
+// At returns an instance of the store that sees the database as of the read timestamp of txn, e.g. a version
+// returned by History. txn must be created by badger.DB.NewTransactionAt on a database opened in managed mode
+// (see badger.OpenManaged), preferably with update set to false so that the instance is read-only.
+// Note that the APIs of the package that run their own transactions, e.g. Verify, Rebuild, BulkLoad and the
+// workers of async indexes, don't support managed mode.
+func (s *Store[I, T, PT]) At(txn *badger.Txn) *Instance[I, T, PT] {
+	return s.Instantiate(txn)
+}
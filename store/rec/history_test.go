@@ -0,0 +1,101 @@
+package rec_test
+
+import (
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstance_History(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").
+		WithInMemory(true).
+		WithNumVersionsToKeep(10).
+		WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]())
+
+	for _, name := range []string{"foo", "bar"} {
+		require.NoError(t, db.Update(func(txn *badger.Txn) error {
+			return store.Instantiate(txn).Set(&plainEntity{Id: 1, Name: name})
+		}))
+	}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return store.Instantiate(txn).Delete(1)
+	}))
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return store.Instantiate(txn).Set(&plainEntity{Id: 10, Name: "other"})
+	}))
+
+	type version struct {
+		ts uint64
+		v  *plainEntity
+	}
+	var versions []version
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		iter, err := store.Instantiate(txn).History(1)
+		if err != nil {
+			return err
+		}
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			v, err := iter.Value()
+			if err != nil {
+				return err
+			}
+			versions = append(versions, version{ts: iter.Key(), v: v})
+		}
+		return nil
+	}))
+
+	require.Len(t, versions, 3)
+	require.Nil(t, versions[0].v)
+	require.Equal(t, &plainEntity{Id: 1, Name: "bar"}, versions[1].v)
+	require.Equal(t, &plainEntity{Id: 1, Name: "foo"}, versions[2].v)
+	require.Greater(t, versions[0].ts, versions[1].ts)
+	require.Greater(t, versions[1].ts, versions[2].ts)
+}
+
+func TestStore_At(t *testing.T) {
+	db, err := badger.OpenManaged(badger.DefaultOptions("").
+		WithInMemory(true).
+		WithNumVersionsToKeep(10).
+		WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]())
+
+	for i, name := range []string{"foo", "bar"} {
+		ts := uint64(i+1) * 10
+		txn := db.NewTransactionAt(ts-1, true)
+		require.NoError(t, store.Instantiate(txn).Set(&plainEntity{Id: 1, Name: name}))
+		require.NoError(t, txn.CommitAt(ts, nil))
+	}
+
+	tests := []struct {
+		ts       uint64
+		expected string
+	}{
+		{ts: 10, expected: "foo"},
+		{ts: 15, expected: "foo"},
+		{ts: 20, expected: "bar"},
+	}
+	for _, test := range tests {
+		txn := db.NewTransactionAt(test.ts, false)
+		v, err := store.At(txn).Get(1)
+		txn.Discard()
+		require.NoError(t, err)
+		require.Equal(t, test.expected, v.Name)
+	}
+
+	txn := db.NewTransactionAt(5, false)
+	defer txn.Discard()
+	_, err = store.At(txn).Get(1)
+	require.ErrorIs(t, err, badger.ErrKeyNotFound)
+}
@@ -0,0 +1,166 @@
+package rec
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	qlexpr "github.com/araddon/qlbridge/expr"
+	qllex "github.com/araddon/qlbridge/lex"
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/expr"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+)
+
+// scanThreshold is the estimated selectivity of an index lookup above which a full scan is preferred.
+const scanThreshold = 0.5
+
+// candidates tries to narrow down the records that may match the given expression using the indexers of the store.
+// It returns false if no indexer can be used, in which case a full scan is needed.
+// When statistics of the indexes are available, the lookup with the least estimated entries is chosen and lookups
+// that are not selective enough are dropped in favor of a full scan. Indexes without statistics are only used
+// if no index with statistics can be used. Lookups that the indexes don't support are skipped and so are
+// the async indexes with pending changes, as they would miss the records written since, and the indexes that
+// don't match their definition (see indexing.ErrDefinitionMismatch).
+// The returned keys are only candidates and must be verified by the actual predicate.
+func (s *Instance[I, T, PT]) candidates(n qlexpr.Node) (badgerutils.Iterator[[]byte, []byte], bool, error) {
+	type candidate struct {
+		idx  *indexing.ExtensionInstance[T]
+		arg  any
+		cost float64
+	}
+
+	idxs := make(map[string]*indexing.ExtensionInstance[T], len(s.indexers))
+	for _, name := range s.indexers {
+		idx, ok := s.base.GetExtension(name).(*indexing.ExtensionInstance[T])
+		if !ok {
+			continue
+		}
+
+		pending, err := idx.Pending()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to check pending changes of index %s: %w", name, err)
+		}
+		if !pending {
+			idxs[name] = idx
+		}
+	}
+
+	var cands []candidate
+	for _, arg := range lookupArgs(n) {
+		for _, name := range s.indexers {
+			idx, ok := idxs[name]
+			if !ok {
+				continue
+			}
+
+			cost := math.Inf(1)
+			est, stats, err := idx.Estimate(arg)
+			switch {
+			case errors.Is(err, indexing.ErrUnsupportedQuery), errors.Is(err, indexing.ErrDefinitionMismatch):
+				continue
+			case errors.Is(err, indexing.ErrNoStats), errors.Is(err, indexing.ErrNoEstimate):
+			case err != nil:
+				return nil, false, fmt.Errorf("failed to estimate lookup on index %s: %w", name, err)
+			case stats.Entries > 0 && est/float64(stats.Entries) > scanThreshold:
+				continue
+			default:
+				cost = est
+			}
+
+			cands = append(cands, candidate{idx: idx, arg: arg, cost: cost})
+		}
+	}
+	slices.SortStableFunc(cands, func(a, b candidate) int {
+		return cmp.Compare(a.cost, b.cost)
+	})
+
+	for _, c := range cands {
+		iter, err := c.idx.Lookup(badger.IteratorOptions{PrefetchValues: false}, c.arg)
+		if errors.Is(err, indexing.ErrUnsupportedQuery) {
+			continue
+		} else if err != nil {
+			return nil, false, fmt.Errorf("failed to lookup index: %w", err)
+		}
+
+		return iter, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// lookupArgs extracts the lookup arguments that all of the matching records must satisfy.
+func lookupArgs(n qlexpr.Node) []any {
+	switch n := n.(type) {
+	case *qlexpr.BinaryNode:
+		switch n.Operator.T {
+		case qllex.TokenLogicAnd, qllex.TokenAnd:
+			return append(lookupArgs(n.Args[0]), lookupArgs(n.Args[1])...)
+		case qllex.TokenLike:
+			id, ok := n.Args[0].(*qlexpr.IdentityNode)
+			if !ok {
+				return nil
+			}
+			str, ok := n.Args[1].(*qlexpr.StringNode)
+			if !ok {
+				return nil
+			}
+			args := []any{expr.NewAssigned(id.Text, expr.NewLike(str.Text))}
+			if prefix := likePrefix(str.Text); prefix != "" {
+				args = append([]any{expr.NewAssigned(id.Text, expr.NewPrefix[any](prefix))}, args...)
+			}
+			return args
+		}
+	case *qlexpr.BooleanNode:
+		if n.Negated() || (n.Operator.T != qllex.TokenLogicAnd && n.Operator.T != qllex.TokenAnd) {
+			return nil
+		}
+		var args []any
+		for _, arg := range n.Args {
+			args = append(args, lookupArgs(arg)...)
+		}
+		return args
+	}
+
+	return nil
+}
+
+// likePrefix returns the literal prefix of the given like pattern that every matching string starts with.
+func likePrefix(pattern string) string {
+	var (
+		sb      strings.Builder
+		escaped bool
+	)
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+			continue
+		case r == '%', r == '*', r == '_', r == '?', r == '[', r == '{':
+			return sb.String()
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// candidateGetter gets the candidates of a query and skips the ones that don't exist, e.g. because the ref
+// of a deleted record is still in an index, by returning nil instead of badger.ErrKeyNotFound.
+type candidateGetter[I comparable, T any, PT Identifiable[I, T]] struct {
+	ins *Instance[I, T, PT]
+}
+
+func (g candidateGetter[I, T, PT]) Get(id I) (*T, error) {
+	r, err := g.ins.Get(id)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	return r, err
+}
@@ -425,13 +425,14 @@ func (e *associationCExtIns[PI, PT, PR, CI, CT, CR]) OnSet(_ context.Context, ke
 		}
 	}
 
-	err = e.p2c.Set(key, refstore.NewRefEntry(pk))
+	exp, _ := extstore.FindExpiry(opts)
+	err = e.p2c.Set(key, refstore.NewRefEntry(pk).WithExpiresAt(exp.ExpiresAt))
 	if err != nil {
 		return fmt.Errorf("failed to set ref in p2c store: %w", err)
 	}
 
 	if e.pidFunc == nil {
-		err = e.c2p.Set(pk, refstore.NewRefEntry(key))
+		err = e.c2p.Set(pk, refstore.NewRefEntry(key).WithExpiresAt(exp.ExpiresAt))
 		if err != nil {
 			return fmt.Errorf("failed to set ref in c2p store: %w", err)
 		}
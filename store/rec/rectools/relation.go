@@ -7,6 +7,7 @@ import (
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
 	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
 	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
 	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
 	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
@@ -141,6 +142,22 @@ func NewCompoundKey[LI, RI any](left LI, right RI) CompoundKey[LI, RI] {
 	return CompoundKey[LI, RI]{Left: left, Right: right}
 }
 
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// Keys are encoded as order-preserving tuples so they can be used as record ids too.
+func (k CompoundKey[LI, RI]) MarshalBinary() ([]byte, error) {
+	return lex.EncodeTuple(k.Left, k.Right)
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (k *CompoundKey[LI, RI]) UnmarshalBinary(data []byte) error {
+	t, err := lex.DecodeTuple(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode compound key: %w", err)
+	}
+
+	return t.Scan(&k.Left, &k.Right)
+}
+
 func (ri *RelationInstance[LI, LT, LR, RI, RT, RR, D, PD]) serializeKey(key CompoundKey[LI, RI]) ([]byte, []byte, error) {
 	lk, err := ri.leftIdCodec.Encode(key.Left)
 	if err != nil {
@@ -396,6 +413,7 @@ func (ei *relExtInstance[MI, MT, MR, CI, CT, CR, D, PD]) OnDelete(_ context.Cont
 
 func (ei *relExtInstance[MI, MT, MR, CI, CT, CR, D, PD]) OnSet(_ context.Context, key []byte, _, _ *MT, opts ...any) error {
 	cpids := findAs[CI](opts)
+	exp, _ := extstore.FindExpiry(opts)
 
 	for _, cpid := range cpids {
 		cpk, err := ei.counterpartyIdCodec.Encode(cpid)
@@ -411,12 +429,12 @@ func (ei *relExtInstance[MI, MT, MR, CI, CT, CR, D, PD]) OnSet(_ context.Context
 			return fmt.Errorf("no record with id %v found in counterparty store", cpid)
 		}
 
-		err = ei.m2c.Set(cpk, refstore.NewRefEntry(key))
+		err = ei.m2c.Set(cpk, refstore.NewRefEntry(key).WithExpiresAt(exp.ExpiresAt))
 		if err != nil {
 			return fmt.Errorf("failed to set %T -> %T ref record: %w", *new(MT), *new(CT), err)
 		}
 
-		err = ei.c2m.Set(key, refstore.NewRefEntry(cpk))
+		err = ei.c2m.Set(key, refstore.NewRefEntry(cpk).WithExpiresAt(exp.ExpiresAt))
 		if err != nil {
 			return fmt.Errorf("failed to set %T -> %T ref record: %w", *new(CT), *new(MT), err)
 		}
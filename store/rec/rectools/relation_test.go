@@ -1,14 +1,17 @@
 package rectools_test
 
 import (
+	"bytes"
 	"testing"
 
 	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
 	"github.com/ehsanranjbar/badgerutils/iters"
 	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
 	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
 	rectools "github.com/ehsanranjbar/badgerutils/store/rec/rectools"
 	"github.com/ehsanranjbar/badgerutils/testutil"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -142,3 +145,21 @@ func (ts *RelationSuite) TestInstance() {
 		testutil.Dump(ts.txn),
 	)
 }
+
+func TestCompoundKey_MarshalBinary(t *testing.T) {
+	k := rectools.NewCompoundKey(int64(-5), "foo")
+	bz, err := k.MarshalBinary()
+	require.NoError(t, err)
+
+	var got rectools.CompoundKey[int64, string]
+	require.NoError(t, got.UnmarshalBinary(bz))
+	require.Equal(t, k, got)
+
+	// Keys sort by their left part first.
+	other, err := rectools.NewCompoundKey(int64(3), "bar").MarshalBinary()
+	require.NoError(t, err)
+	require.Negative(t, bytes.Compare(bz, other))
+
+	c := codec.CodecFor[rectools.CompoundKey[int64, string]]()
+	require.NotNil(t, c)
+}
@@ -0,0 +1,72 @@
+package rec
+
+import (
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+)
+
+// Rotate re-encrypts the records of the store that are not encrypted with the current key of kp
+// by setting them again through extstore.Rotate, so the indexes stay consistent.
+// The store must be created by NewWithCodec with a codec.EncryptedCodec as its outermost codec.
+// At most batchSize records are re-encrypted in each transaction and the number of re-encrypted records is returned.
+// Rotate can be safely run again if it fails in the middle. Like extstore.Rotate, it doesn't support
+// databases opened in managed mode.
+func Rotate[
+	I comparable,
+	T any,
+	PT Identifiable[I, T],
+](
+	db *badger.DB,
+	s *Store[I, T, PT],
+	kp codec.KeyProvider,
+	batchSize int,
+) (int, error) {
+	return extstore.Rotate(db, s.base, kp, batchSize, extstore.WithRewriteHook(s.setId))
+}
+
+// Migrate rewrites the records of the store that are written in an older format of its codec
+// (see codec.Upgradable) through extstore.Migrate, so the indexes stay consistent.
+// At most batchSize records are rewritten in each transaction and the number of rewritten records is returned.
+// Migrate can be safely run again if it fails in the middle.
+// Since the extensions only see the upgraded records, the definition of the indexes on the fields
+// changed by the upgraders should be changed as well so that they're rebuilt.
+// Like extstore.Migrate, it doesn't support databases opened in managed mode.
+func Migrate[
+	I comparable,
+	T any,
+	PT Identifiable[I, T],
+](
+	db *badger.DB,
+	s *Store[I, T, PT],
+	batchSize int,
+) (int, error) {
+	return extstore.Migrate(db, s.base, batchSize, extstore.WithRewriteHook(s.setId))
+}
+
+// Rebuild rebuilds the extensions of the store that need to be rebuilt, e.g. the indexes whose definition has
+// changed, in batches of batchSize, see extstore.Rebuild. It doesn't support databases opened in managed mode.
+func Rebuild[
+	I comparable,
+	T any,
+	PT Identifiable[I, T],
+](
+	db *badger.DB,
+	s *Store[I, T, PT],
+	batchSize int,
+) (int, error) {
+	return extstore.Rebuild(db, s.base, batchSize)
+}
+
+// setId sets the id of the record from its key, as ids are not serialized as part of the records.
+func (s *Store[I, T, PT]) setId(key []byte, r *T) error {
+	id, err := s.idCodec.Decode(key)
+	if err != nil {
+		return fmt.Errorf("failed to decode id: %w", err)
+	}
+
+	PT(r).SetId(id)
+	return nil
+}
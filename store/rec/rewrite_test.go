@@ -0,0 +1,152 @@
+package rec_test
+
+import (
+	"bytes"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/ehsanranjbar/badgerutils/indexing/concat"
+	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/ehsanranjbar/badgerutils/schema"
+	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotate(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	keys := codec.NewKeyRing()
+	require.NoError(t, keys.Add(1, bytes.Repeat([]byte{1}, 32)))
+
+	nameIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[plainEntity](false),
+		&lex.Encoder{},
+		concat.NewComponent("Name").WithSize(8),
+	)
+	require.NoError(t, err)
+	var i int64
+	store := recstore.NewWithCodec[int64, plainEntity](
+		nil,
+		codec.Encrypted(codec.JSON[*plainEntity](), keys).AllowPlaintext(),
+	).
+		WithIdFunc(func(_ *plainEntity) (int64, error) {
+			i++
+			return i, nil
+		}).
+		WithIndexer("name", nameIndexer)
+
+	names := []string{"alice", "bob", "carol", "dave", "eve"}
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		for _, name := range names {
+			if err := ins.Set(&plainEntity{Name: name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	keyIds := func() []uint32 {
+		var ids []uint32
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			iter := txn.NewIterator(badger.IteratorOptions{Prefix: []byte{'d'}})
+			defer iter.Close()
+			for iter.Rewind(); iter.Valid(); iter.Next() {
+				err := iter.Item().Value(func(val []byte) error {
+					id, ok := codec.EncryptionKeyId(val)
+					require.True(t, ok)
+					ids = append(ids, id)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		return ids
+	}
+	require.Equal(t, []uint32{1, 1, 1, 1, 1}, keyIds())
+
+	n, err := recstore.Rotate(db, store, keys, 2)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	require.NoError(t, keys.Add(2, bytes.Repeat([]byte{2}, 32)))
+	n, err = recstore.Rotate(db, store, keys, 2)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	require.Equal(t, []uint32{2, 2, 2, 2, 2}, keyIds())
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		for id, name := range names {
+			v, err := ins.Get(int64(id + 1))
+			require.NoError(t, err)
+			require.Equal(t, name, v.Name)
+		}
+
+		iter, err := ins.Query(`Name = "carol"`)
+		require.NoError(t, err)
+		defer iter.Close()
+		values, err := iters.Collect(iter)
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+		return nil
+	}))
+}
+
+func TestMigrate(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	nameIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[plainEntity](false),
+		&lex.Encoder{},
+		concat.NewComponent("Name").WithSize(8),
+	)
+	require.NoError(t, err)
+	newStore := func(c codec.Codec[*plainEntity]) *recstore.Store[int64, plainEntity, *plainEntity] {
+		return recstore.NewWithCodec[int64, plainEntity](nil, c).WithIndexer("name", nameIndexer)
+	}
+
+	// Version 0 stored the names in upper case.
+	v0 := newStore(codec.JSON[*plainEntity]())
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := v0.Instantiate(txn)
+		for id, name := range []string{"ALICE", "BOB", "CAROL"} {
+			if err := ins.Set(&plainEntity{Id: int64(id + 1), Name: name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	v1 := newStore(codec.Versioned(codec.JSON[*plainEntity](), 1).
+		WithUpgrader(0, func(old []byte) ([]byte, error) {
+			return bytes.ToLower(old), nil
+		}))
+
+	n, err := recstore.Migrate(db, v1, 2)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	n, err = recstore.Migrate(db, v1, 2)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		iter, err := v1.Instantiate(txn).Query(`Name = "bob"`)
+		require.NoError(t, err)
+		defer iter.Close()
+		values, err := iters.Collect(iter)
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+		return nil
+	}))
+}
@@ -3,6 +3,7 @@ package rec
 import (
 	"encoding"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/araddon/qlbridge/expr"
@@ -15,16 +16,22 @@ import (
 	"github.com/ehsanranjbar/badgerutils/iters"
 	"github.com/ehsanranjbar/badgerutils/schema"
 	extstore "github.com/ehsanranjbar/badgerutils/store/ext"
+	sstore "github.com/ehsanranjbar/badgerutils/store/serialized"
 	"golang.org/x/exp/constraints"
 )
 
+// Identifiable is a model for something that has an unique id assigned to it.
+type Identifiable[I comparable, T any] interface {
+	*T
+	GetId() I
+	SetId(I)
+}
+
 // Record is a model for something that is serializable and has an unique id assigned to it.
 // One thing to consider here is that the id is serialized as key in the store so there's no need to serialize it
 // as part of the value.
 type Record[I comparable, T any] interface {
-	*T
-	GetId() I
-	SetId(I)
+	Identifiable[I, T]
 	encoding.BinaryMarshaler
 	encoding.BinaryUnmarshaler
 }
@@ -33,9 +40,9 @@ type Record[I comparable, T any] interface {
 type Store[
 	I comparable,
 	T any,
-	PT Record[I, T],
+	PT Identifiable[I, T],
 ] struct {
-	base          *extstore.Store[T, PT]
+	base          *extstore.Store[T, *T]
 	idFunc        func(*T) (I, error)
 	idCodec       codec.Codec[I]
 	indexers      map[string]*indexing.Extension[T]
@@ -52,9 +59,37 @@ func New[
 	PT Record[I, T],
 ](
 	base badgerutils.Instantiator[badgerutils.BadgerStore],
+) *Store[I, T, PT] {
+	return newStore[I, T, PT](base, sstore.BinaryCodec[T, PT]())
+}
+
+// NewWithCodec creates a new Store that serializes records using the given codec
+// which allows plain structs and generated protobuf messages to be used as records.
+func NewWithCodec[
+	I comparable,
+	T any,
+	PT Identifiable[I, T],
+](
+	base badgerutils.Instantiator[badgerutils.BadgerStore],
+	c codec.Codec[*T],
+) *Store[I, T, PT] {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+
+	return newStore[I, T, PT](base, c)
+}
+
+func newStore[
+	I comparable,
+	T any,
+	PT Identifiable[I, T],
+](
+	base badgerutils.Instantiator[badgerutils.BadgerStore],
+	c codec.Codec[*T],
 ) *Store[I, T, PT] {
 	return &Store[I, T, PT]{
-		base:      extstore.New[T, PT](base),
+		base:      extstore.NewWithCodec(base, c),
 		idCodec:   codec.CodecFor[I](),
 		indexers:  map[string]*indexing.Extension[T]{},
 		extractor: schema.NewReflectPathExtractor[*T](true),
@@ -118,8 +153,12 @@ func (s *Store[I, T, PT]) WithFlatExtractor(e schema.PathExtractor[[]byte]) *Sto
 	return s
 }
 
-// WithIndexer adds an indexer to the store.
-func (s *Store[I, T, PT]) WithIndexer(name string, idx indexing.Indexer[T]) *Store[I, T, PT] {
+// WithIndexer adds an indexer to the store with the given options of its extension, e.g. indexing.WithAsync.
+func (s *Store[I, T, PT]) WithIndexer(
+	name string,
+	idx indexing.Indexer[T],
+	opts ...func(*indexing.Extension[T]),
+) *Store[I, T, PT] {
 	if s.initialized {
 		panic("store already initialized")
 	}
@@ -128,7 +167,7 @@ func (s *Store[I, T, PT]) WithIndexer(name string, idx indexing.Indexer[T]) *Sto
 		panic("indexer already exists")
 	}
 
-	ext := indexing.NewExtension(idx).(*indexing.Extension[T])
+	ext := indexing.NewExtension(idx, opts...).(*indexing.Extension[T])
 	s.base.WithExtension(name, ext)
 	s.indexers[name] = ext
 	return s
@@ -149,6 +188,12 @@ func (s *Store[I, T, PT]) Prefix() []byte {
 	return s.base.Prefix()
 }
 
+// Verify checks the persisted state of the extensions against their definitions, see extstore.Store.Verify.
+// It doesn't support databases opened in managed mode.
+func (s *Store[I, T, PT]) Verify(db *badger.DB) error {
+	return s.base.Verify(db)
+}
+
 // Instantiate implements the badgerutils.Instantiator interface.
 func (s *Store[I, T, PT]) Instantiate(txn *badger.Txn) *Instance[I, T, PT] {
 	// Locking any changes to the store's configuration on first instantiation.
@@ -169,7 +214,17 @@ func (s *Store[I, T, PT]) Instantiate(txn *badger.Txn) *Instance[I, T, PT] {
 		idFunc:    s.idFunc,
 		idCodec:   s.idCodec,
 		extractor: s.extractor,
+		indexers:  s.indexerNames(),
+	}
+}
+
+func (s *Store[I, T, PT]) indexerNames() []string {
+	names := make([]string, 0, len(s.indexers))
+	for name := range s.indexers {
+		names = append(names, name)
 	}
+	slices.Sort(names)
+	return names
 }
 
 // IdCodec returns the id codec.
@@ -191,12 +246,13 @@ func (s *Store[I, T, PT]) Indexer(name string) *indexing.Extension[T] {
 type Instance[
 	I comparable,
 	T any,
-	PT Record[I, T],
+	PT Identifiable[I, T],
 ] struct {
-	base      *extstore.Instance[T, PT]
+	base      *extstore.Instance[T, *T]
 	idFunc    func(*T) (I, error)
 	idCodec   codec.Codec[I]
 	extractor schema.PathExtractor[*T]
+	indexers  []string
 }
 
 // Delete implements the badgerutils.StoreInstance interface.
@@ -230,39 +286,73 @@ func (s *Instance[I, T, PT]) NewIterator(opts badger.IteratorOptions) *Iterator[
 }
 
 // Set implements the badgerutils.StoreInstance interface.
+// opts are passed to the extensions and badgerutils.EntryOption values are applied to the entry of the record,
+// e.g. badgerutils.WithTTL makes the record and the entries of its extensions expire.
 func (s *Instance[I, T, PT]) Set(v *T, opts ...any) error {
+	key, err := recordKey[I, T, PT](v, s.idFunc, s.idCodec)
+	if err != nil {
+		return err
+	}
+
+	return s.base.SetWithOptions(key, v, opts...)
+}
+
+// recordKey assigns an id to the record using idFunc if it has a zero id and returns its encoded id.
+func recordKey[
+	I comparable,
+	T any,
+	PT Identifiable[I, T],
+](v *T, idFunc func(*T) (I, error), idCodec codec.Codec[I]) ([]byte, error) {
 	var zero I
 	if PT(v).GetId() == zero {
-		if s.idFunc == nil {
-			return fmt.Errorf("zero id with no id func")
+		if idFunc == nil {
+			return nil, fmt.Errorf("zero id with no id func")
 		}
 
-		id, err := s.idFunc(v)
+		id, err := idFunc(v)
 		if err != nil {
-			return fmt.Errorf("failed to get id: %w", err)
+			return nil, fmt.Errorf("failed to get id: %w", err)
 		}
 
 		PT(v).SetId(id)
 	}
 
-	key, err := s.idCodec.Encode(PT(v).GetId())
+	key, err := idCodec.Encode(PT(v).GetId())
 	if err != nil {
-		return fmt.Errorf("failed to encode id: %w", err)
+		return nil, fmt.Errorf("failed to encode id: %w", err)
 	}
 
-	return s.base.SetWithOptions(key, v, opts...)
+	return key, nil
 }
 
 // Query returns the query for the store.
+// If one of the indexers can narrow down the candidates of the query (e.g. a trigram index for LIKE patterns)
+// only the candidates are verified, otherwise the whole store is scanned.
 func (s *Instance[I, T, PT]) Query(q string) (badgerutils.Iterator[I, *T], error) {
 	qe, err := expr.ParseExpression(q)
 	if err != nil {
 		return nil, err
 	}
 
+	var base badgerutils.Iterator[I, *T]
+	keys, ok, err := s.candidates(qe)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		base = iters.Lookup(candidateGetter[I, T, PT]{s}, iters.Map(keys, func(k []byte, _ *badger.Item) (I, error) {
+			return s.idCodec.Decode(k)
+		}))
+	} else {
+		base = s.NewIterator(badger.DefaultIteratorOptions)
+	}
+
 	iter := iters.Filter(
-		s.NewIterator(badger.DefaultIteratorOptions),
+		base,
 		func(r *T, item *badger.Item) bool {
+			if r == nil {
+				return false
+			}
 			ctx := qlutil.NewContextWrapper(PT(r).GetId(), r, s.extractor, nil)
 			t, _ := qlvm.MatchesExpr(ctx, qe)
 			return t
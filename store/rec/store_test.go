@@ -1,11 +1,22 @@
 package rec_test
 
 import (
+	"bytes"
+	"context"
 	"testing"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	"github.com/ehsanranjbar/badgerutils/codec/lex"
+	"github.com/ehsanranjbar/badgerutils/indexing"
+	"github.com/ehsanranjbar/badgerutils/indexing/concat"
+	"github.com/ehsanranjbar/badgerutils/indexing/trigram"
 	"github.com/ehsanranjbar/badgerutils/iters"
+	"github.com/ehsanranjbar/badgerutils/schema"
 	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
+	sstore "github.com/ehsanranjbar/badgerutils/store/serialized"
 	"github.com/ehsanranjbar/badgerutils/testutil"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -84,3 +95,267 @@ func TestStore(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestStore_QueryWithIndex(t *testing.T) {
+	store := testutil.NewEntityStore([]byte("entities")).
+		WithIndexer("name", trigram.New(schema.NewReflectPathExtractor[testutil.SampleEntity](false), "Name"))
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	for _, name := range []string{"foobar", "barbaz", "bazfoo", "qux"} {
+		err := ins.Set(testutil.NewSampleEntity(name))
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`Name LIKE "%foo%"`, []string{"foobar", "bazfoo"}},
+		{`Name LIKE "%foo%" AND Name LIKE "%bar%"`, []string{"foobar"}},
+		{`Name LIKE "baz%"`, []string{"bazfoo"}},
+		{`Name LIKE "%oo%"`, []string{"foobar", "bazfoo"}},
+		{`NOT (Name LIKE "%foo%")`, []string{"barbaz", "qux"}},
+	}
+
+	for _, test := range tests {
+		iter, err := ins.Query(test.query)
+		require.NoError(t, err)
+
+		values, err := iters.Collect(iter)
+		require.NoError(t, err)
+		iter.Close()
+
+		var names []string
+		for _, v := range values {
+			names = append(names, v.Name)
+		}
+		require.ElementsMatch(t, test.expected, names, test.query)
+	}
+}
+
+func TestStore_QueryWithPrefixIndex(t *testing.T) {
+	nameIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[testutil.SampleEntity](false),
+		&lex.Encoder{},
+		concat.NewComponent("Name").WithSize(8).Desc(),
+	)
+	require.NoError(t, err)
+	store := testutil.NewEntityStore([]byte("entities")).WithIndexer("name", nameIndexer)
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	for _, name := range []string{"foobar", "barbaz", "bazfoo", "ba%", "qux"} {
+		err := ins.Set(testutil.NewSampleEntity(name))
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`Name LIKE "ba%"`, []string{"barbaz", "bazfoo", "ba%"}},
+		{`Name LIKE "baz*"`, []string{"bazfoo"}},
+		{`Name LIKE "b?r%"`, []string{"barbaz"}},
+		{`Name LIKE "%oo%"`, []string{"foobar", "bazfoo"}},
+		{`Name LIKE "qux"`, []string{"qux"}},
+	}
+
+	for _, test := range tests {
+		iter, err := ins.Query(test.query)
+		require.NoError(t, err)
+
+		values, err := iters.Collect(iter)
+		require.NoError(t, err)
+		iter.Close()
+
+		var names []string
+		for _, v := range values {
+			names = append(names, v.Name)
+		}
+		require.ElementsMatch(t, test.expected, names, test.query)
+	}
+}
+
+func TestStore_QueryWithAsyncIndex(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := testutil.NewEntityStore([]byte("entities")).WithIndexer(
+		"name",
+		trigram.New(schema.NewReflectPathExtractor[testutil.SampleEntity](false), "Name"),
+		indexing.WithAsync[testutil.SampleEntity](),
+	)
+	query := func() []string {
+		var names []string
+		require.NoError(t, db.View(func(txn *badger.Txn) error {
+			iter, err := store.Instantiate(txn).Query(`Name LIKE "%foo%"`)
+			if err != nil {
+				return err
+			}
+			defer iter.Close()
+
+			values, err := iters.Collect(iter)
+			for _, v := range values {
+				names = append(names, v.Name)
+			}
+			return err
+		}))
+		return names
+	}
+
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		for _, name := range []string{"foobar", "qux"} {
+			if err := ins.Set(testutil.NewSampleEntity(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	w := store.Indexer("name").StartWorker(db, indexing.WithWorkerInterval(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, w.Sync(ctx))
+	w.Close()
+	require.ElementsMatch(t, []string{"foobar"}, query())
+
+	// A pending insert and a pending delete that the index doesn't reflect yet.
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		if err := ins.Set(testutil.NewSampleEntity("bazfoo")); err != nil {
+			return err
+		}
+		return ins.Delete(1)
+	}))
+	require.ElementsMatch(t, []string{"bazfoo"}, query())
+}
+
+type plainEntity struct {
+	Id   int64 `json:"-"`
+	Name string
+	Tags []string
+}
+
+func (e *plainEntity) GetId() int64 { return e.Id }
+
+func (e *plainEntity) SetId(id int64) { e.Id = id }
+
+func TestStore_WithCodec(t *testing.T) {
+	nameIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[plainEntity](false),
+		&lex.Encoder{},
+		concat.NewComponent("Name").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	var i int64
+	store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]()).
+		WithIdFunc(func(_ *plainEntity) (int64, error) {
+			i++
+			return i, nil
+		}).
+		WithIndexer("name", nameIndexer)
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	for _, name := range []string{"foo", "bar", "baz"} {
+		err := ins.Set(&plainEntity{Name: name, Tags: []string{name}})
+		require.NoError(t, err)
+	}
+
+	v, err := ins.Get(2)
+	require.NoError(t, err)
+	require.Equal(t, &plainEntity{Id: 2, Name: "bar", Tags: []string{"bar"}}, v)
+
+	iter, err := ins.Query(`Name = "baz"`)
+	require.NoError(t, err)
+	values, err := iters.Collect(iter)
+	require.NoError(t, err)
+	iter.Close()
+	require.Len(t, values, 1)
+	require.Equal(t, []string{"baz"}, values[0].Tags)
+}
+
+func TestStore_WithCompressedCodec(t *testing.T) {
+	type blob = recstore.Object[uuid.UUID, []byte]
+
+	store := recstore.NewWithCodec[uuid.UUID, blob](
+		nil,
+		codec.Compressed(sstore.BinaryCodec[blob](), codec.Zstd),
+	)
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	v := recstore.NewObjectWithId(uuid.New(), bytes.Repeat([]byte("image"), 1024))
+	err := ins.Set(v)
+	require.NoError(t, err)
+
+	actual, err := ins.Get(v.Id)
+	require.NoError(t, err)
+	require.Equal(t, v, actual)
+}
+
+func TestStore_SetWithTTL(t *testing.T) {
+	nameIndexer, err := concat.New(
+		schema.NewReflectPathExtractor[plainEntity](false),
+		&lex.Encoder{},
+		concat.NewComponent("Name").WithSize(8),
+	)
+	require.NoError(t, err)
+
+	store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]()).
+		WithIndexer("name", nameIndexer)
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	// expiries returns the expiries of the records followed by the ones of the refs in the name index.
+	expiries := func() []uint64 {
+		var exps []uint64
+		for _, prefix := range [][]byte{[]byte("d"), []byte("xname")} {
+			iter := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+			for iter.Rewind(); iter.Valid(); iter.Next() {
+				exps = append(exps, iter.Item().ExpiresAt())
+			}
+			iter.Close()
+		}
+		return exps
+	}
+
+	ttl := badgerutils.WithTTL(time.Hour)
+	expiresAt := badgerutils.NewEntryOptions(ttl).ExpiresAt
+	require.NoError(t, ins.Set(&plainEntity{Id: 1, Name: "foo"}, ttl))
+	require.Equal(t, []uint64{expiresAt, expiresAt}, expiries())
+
+	t.Run("Unchanged", func(t *testing.T) {
+		later := expiresAt + 60
+		require.NoError(t, ins.Set(&plainEntity{Id: 1, Name: "foo"}, badgerutils.WithExpiresAt(later)))
+		require.Equal(t, []uint64{later, later}, expiries())
+	})
+
+	t.Run("WithoutTTL", func(t *testing.T) {
+		require.NoError(t, ins.Set(&plainEntity{Id: 1, Name: "bar"}))
+		require.Equal(t, []uint64{0, 0}, expiries())
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		past := uint64(time.Now().Add(-time.Minute).Unix())
+		require.NoError(t, ins.Set(&plainEntity{Id: 2, Name: "baz"}, badgerutils.WithExpiresAt(past)))
+
+		_, err := ins.Get(2)
+		require.ErrorIs(t, err, badger.ErrKeyNotFound)
+
+		iter, err := ins.Query(`Name = "baz"`)
+		require.NoError(t, err)
+		values, err := iters.Collect(iter)
+		require.NoError(t, err)
+		iter.Close()
+		require.Empty(t, values)
+	})
+}
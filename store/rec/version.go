@@ -0,0 +1,83 @@
+package rec
+
+import (
+	"errors"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// ErrVersionMismatch is returned by SetIfVersion and DeleteIfVersion when the record is changed
+// since the expected version is read.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// GetWithVersion is similar to Get, but it also returns the version of the record which is the commit timestamp
+// of its last write. It can be used as an ETag and passed to SetIfVersion or DeleteIfVersion in a later transaction.
+// Unlike Get, a stale record of a versioned codec isn't written back since that would change its version.
+func (s *Instance[I, T, PT]) GetWithVersion(id I) (*T, uint64, error) {
+	key, err := s.idCodec.Encode(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	item, err := s.base.GetItem(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	r, err := s.base.Decode(item)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode record: %w", err)
+	}
+	PT(r).SetId(id)
+	return r, item.Version(), nil
+}
+
+// SetIfVersion is similar to Set, but it fails with ErrVersionMismatch if the current version of the record
+// is not expected. An expected version of zero means that the record must not exist.
+// As the current version is read in the transaction, a concurrent write after the check makes the commit fail
+// with badger.ErrConflict.
+// A record with a zero id gets its id from the id func before the check, the same as Set.
+func (s *Instance[I, T, PT]) SetIfVersion(v *T, expected uint64, opts ...any) error {
+	key, err := recordKey[I, T, PT](v, s.idFunc, s.idCodec)
+	if err != nil {
+		return err
+	}
+	if err := s.checkVersion(key, expected); err != nil {
+		return err
+	}
+
+	return s.base.SetWithOptions(key, v, opts...)
+}
+
+// DeleteIfVersion is similar to Delete, but it fails with ErrVersionMismatch if the current version of the record
+// is not expected.
+func (s *Instance[I, T, PT]) DeleteIfVersion(id I, expected uint64) error {
+	key, err := s.idCodec.Encode(id)
+	if err != nil {
+		return fmt.Errorf("failed to encode id: %w", err)
+	}
+	if err := s.checkVersion(key, expected); err != nil {
+		return err
+	}
+
+	return s.Delete(id)
+}
+
+// checkVersion returns ErrVersionMismatch if the current version of the record is not expected.
+// Records that don't exist have the version zero. The value of the record is neither decoded nor written back.
+func (s *Instance[I, T, PT]) checkVersion(key []byte, expected uint64) error {
+	var actual uint64
+	item, err := s.base.GetItem(key)
+	switch {
+	case errors.Is(err, badger.ErrKeyNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to get record: %w", err)
+	default:
+		actual = item.Version()
+	}
+
+	if actual != expected {
+		return fmt.Errorf("%w: expected %d, got %d", ErrVersionMismatch, expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package rec_test
+
+import (
+	"bytes"
+
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	recstore "github.com/ehsanranjbar/badgerutils/store/rec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstance_SetIfVersion(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]())
+
+	setIfVersion := func(name string, expected uint64) error {
+		return db.Update(func(txn *badger.Txn) error {
+			return store.Instantiate(txn).SetIfVersion(&plainEntity{Id: 1, Name: name}, expected)
+		})
+	}
+	getWithVersion := func() (*plainEntity, uint64) {
+		var (
+			v       *plainEntity
+			version uint64
+		)
+		require.NoError(t, db.View(func(txn *badger.Txn) (err error) {
+			v, version, err = store.Instantiate(txn).GetWithVersion(1)
+			return err
+		}))
+		return v, version
+	}
+
+	require.NoError(t, setIfVersion("foo", 0))
+	require.ErrorIs(t, setIfVersion("foo", 0), recstore.ErrVersionMismatch)
+
+	v, version := getWithVersion()
+	require.Equal(t, &plainEntity{Id: 1, Name: "foo"}, v)
+	require.NotZero(t, version)
+
+	require.NoError(t, setIfVersion("bar", version))
+	require.ErrorIs(t, setIfVersion("baz", version), recstore.ErrVersionMismatch)
+
+	v, newVersion := getWithVersion()
+	require.Equal(t, "bar", v.Name)
+	require.Greater(t, newVersion, version)
+
+	t.Run("DeleteIfVersion", func(t *testing.T) {
+		err := db.Update(func(txn *badger.Txn) error {
+			return store.Instantiate(txn).DeleteIfVersion(1, version)
+		})
+		require.ErrorIs(t, err, recstore.ErrVersionMismatch)
+
+		err = db.Update(func(txn *badger.Txn) error {
+			return store.Instantiate(txn).DeleteIfVersion(1, newVersion)
+		})
+		require.NoError(t, err)
+
+		err = db.View(func(txn *badger.Txn) error {
+			_, err := store.Instantiate(txn).Get(1)
+			return err
+		})
+		require.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		txn1 := db.NewTransaction(true)
+		defer txn1.Discard()
+		txn2 := db.NewTransaction(true)
+		defer txn2.Discard()
+
+		require.NoError(t, store.Instantiate(txn1).SetIfVersion(&plainEntity{Id: 2, Name: "foo"}, 0))
+		require.NoError(t, store.Instantiate(txn2).SetIfVersion(&plainEntity{Id: 2, Name: "bar"}, 0))
+		require.NoError(t, txn1.Commit())
+		require.ErrorIs(t, txn2.Commit(), badger.ErrConflict)
+	})
+}
+
+func TestInstance_GetWithVersionWriteBack(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	v0 := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]())
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return v0.Instantiate(txn).Set(&plainEntity{Id: 1, Name: "FOO"})
+	}))
+
+	v1 := recstore.NewWithCodec[int64, plainEntity](nil, codec.Versioned(codec.JSON[*plainEntity](), 1).
+		WithUpgrader(0, func(old []byte) ([]byte, error) {
+			return bytes.ToLower(old), nil
+		}).
+		WithWriteBack())
+
+	var version uint64
+	require.NoError(t, db.Update(func(txn *badger.Txn) (err error) {
+		var v *plainEntity
+		v, version, err = v1.Instantiate(txn).GetWithVersion(1)
+		require.Equal(t, "foo", v.Name)
+		return err
+	}))
+
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return v1.Instantiate(txn).SetIfVersion(&plainEntity{Id: 1, Name: "bar"}, version)
+	}))
+}
+
+func TestInstance_SetIfVersionZeroId(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var lastId int64
+	store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]()).
+		WithIdFunc(func(_ *plainEntity) (int64, error) {
+			lastId++
+			return lastId, nil
+		})
+
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		ins := store.Instantiate(txn)
+		if err := ins.Set(&plainEntity{Name: "foo"}); err != nil {
+			return err
+		}
+
+		v := &plainEntity{Name: "bar"}
+		if err := ins.SetIfVersion(v, 0); err != nil {
+			return err
+		}
+		require.Equal(t, int64(2), v.Id)
+		return nil
+	}))
+
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		store := recstore.NewWithCodec[int64, plainEntity](nil, codec.JSON[*plainEntity]())
+		err := store.Instantiate(txn).SetIfVersion(&plainEntity{Name: "baz"}, 0)
+		require.Error(t, err)
+		return nil
+	}))
+}
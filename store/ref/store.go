@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
@@ -36,6 +37,16 @@ func (s *Store) Prefix() []byte {
 	return s.prefix
 }
 
+// Entry returns the badger.Entry of a Ref with its whole key including the prefix of the store,
+// so that it can be written without a transaction, e.g. by badger.WriteBatch.
+func (s *Store) Entry(key []byte, e RefEntry) (*badger.Entry, error) {
+	if len(key) > math.MaxUint8 {
+		return nil, fmt.Errorf("key is too long")
+	}
+
+	return newEntry(s.prefix, key, e), nil
+}
+
 func (s *Store) Instantiate(txn *badger.Txn) badgerutils.StoreInstance[
 	[]byte,
 	[]byte,
@@ -57,6 +68,7 @@ func (s *Store) Instantiate(txn *badger.Txn) badgerutils.StoreInstance[
 type RefEntry struct {
 	Prefix        []byte
 	OptionalValue []byte // Value stored as the value of index record that can be used in index only queries
+	ExpiresAt     uint64 // Unix time in seconds that the ref expires at, zero means it never expires
 }
 
 // NewRefEntry creates a new RefEntry
@@ -72,6 +84,12 @@ func (e RefEntry) WithValue(value []byte) RefEntry {
 	return e
 }
 
+// WithExpiresAt sets the unix time in seconds that the RefEntry expires at
+func (e RefEntry) WithExpiresAt(ts uint64) RefEntry {
+	e.ExpiresAt = ts
+	return e
+}
+
 // Instance is a store that works with serialized values and also keeps track of multiple secondary indexes.
 type Instance struct {
 	base   badgerutils.BadgerStore
@@ -133,15 +151,20 @@ func (s *Instance) Set(key []byte, e RefEntry) error {
 		return fmt.Errorf("key is too long")
 	}
 
-	item := badger.NewEntry(append(e.Prefix, key...), e.OptionalValue).WithMeta(uint8(len(key)))
-	return s.base.SetEntry(item)
+	return s.base.SetEntry(newEntry(nil, key, e))
+}
+
+func newEntry(prefix, key []byte, e RefEntry) *badger.Entry {
+	item := badger.NewEntry(slices.Concat(prefix, e.Prefix, key), e.OptionalValue).WithMeta(uint8(len(key)))
+	item.ExpiresAt = e.ExpiresAt
+	return item
 }
 
 // NewIterator creates a new reference iterator
 func (s *Instance) NewIterator(opts badger.IteratorOptions) badgerutils.Iterator[[]byte, []byte] {
 	var iter badgerutils.BadgerIterator = s.base.NewIterator(opts)
 	if s.prefix != nil {
-		iter = pstore.NewIterator(iter, s.prefix)
+		iter = pstore.NewIterator(iter, s.prefix).WithOptions(opts)
 	}
 
 	return newIterator(iter)
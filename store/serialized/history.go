@@ -0,0 +1,89 @@
+package serialized
+
+import (
+	"bytes"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
+	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
+)
+
+var _ badgerutils.Iterator[uint64, *struct{}] = (*HistoryIterator[struct{}])(nil)
+
+// HistoryIterator is an iterator over the versions of a single key from the newest to the oldest.
+// The keys of the iterator are the commit versions and the values of the versions that deleted
+// the key or are expired are nil.
+type HistoryIterator[T any] struct {
+	base  *pstore.Iterator
+	key   []byte
+	codec codec.Codec[*T]
+}
+
+// History returns an iterator over the versions of the key that are kept by badger,
+// see badger.Options.NumVersionsToKeep. Stale values are not written back.
+func (s *Instance[T, PT]) History(key []byte) *HistoryIterator[T] {
+	iter := s.base.NewIterator(badger.IteratorOptions{
+		Prefix:      key,
+		AllVersions: true,
+	})
+
+	return &HistoryIterator[T]{
+		base:  pstore.NewIterator(iter, s.Prefix()),
+		key:   bytes.Clone(key),
+		codec: s.codec,
+	}
+}
+
+// Close closes the iterator.
+func (it *HistoryIterator[T]) Close() {
+	it.base.Close()
+}
+
+// Item returns the current item.
+func (it *HistoryIterator[T]) Item() *badger.Item {
+	return it.base.Item()
+}
+
+// Next moves to the next version.
+func (it *HistoryIterator[T]) Next() {
+	it.base.Next()
+}
+
+// Rewind moves to the newest version.
+func (it *HistoryIterator[T]) Rewind() {
+	it.base.Rewind()
+}
+
+// Seek is the same as Rewind as the iterator only iterates over the versions of a single key.
+func (it *HistoryIterator[T]) Seek(_ []byte) {
+	it.Rewind()
+}
+
+// Valid returns if the iterator is valid.
+func (it *HistoryIterator[T]) Valid() bool {
+	return it.base.Valid() && bytes.Equal(it.base.Key(), it.key)
+}
+
+// Key returns the commit version of the current item.
+func (it *HistoryIterator[T]) Key() uint64 {
+	return it.base.Item().Version()
+}
+
+// Value returns the current version of the value or nil if the key is deleted or expired at this version.
+func (it *HistoryIterator[T]) Value() (value *T, err error) {
+	item := it.base.Item()
+	if item.IsDeletedOrExpired() {
+		return nil, nil
+	}
+
+	err = item.Value(func(val []byte) error {
+		if len(val) == 0 {
+			value = new(T)
+			return nil
+		}
+		value, err = it.codec.Decode(val)
+		return err
+	})
+	return value, err
+}
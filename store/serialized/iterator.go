@@ -1,15 +1,20 @@
 package serialized
 
 import (
+	"bytes"
+
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
 )
 
 // Iterator is an iterator that unmarshal the value.
-type Iterator[T any, PT BSP[T]] struct {
+type Iterator[T any, PT Pointer[T]] struct {
 	base        badgerutils.BadgerIterator
+	codec       codec.Codec[*T]
 	keyProvider keyProvider
 	cachedValue *T
+	onStale     func(item *badger.Item, key []byte, v *T) error
 }
 
 type keyProvider interface {
@@ -18,10 +23,20 @@ type keyProvider interface {
 
 // NewIterator creates a new serialized iterator.
 func NewIterator[T any, PT BSP[T]](base badgerutils.BadgerIterator) *Iterator[T, PT] {
+	return newIterator[T, PT](base, BinaryCodec[T, PT]())
+}
+
+// NewIteratorWithCodec creates a new serialized iterator that decodes values using the given codec.
+func NewIteratorWithCodec[T any](base badgerutils.BadgerIterator, c codec.Codec[*T]) *Iterator[T, *T] {
+	return newIterator[T, *T](base, c)
+}
+
+func newIterator[T any, PT Pointer[T]](base badgerutils.BadgerIterator, c codec.Codec[*T]) *Iterator[T, PT] {
 	kp, _ := base.(keyProvider)
 
 	return &Iterator[T, PT]{
 		base:        base,
+		codec:       c,
 		keyProvider: kp,
 	}
 }
@@ -78,13 +93,29 @@ func (it *Iterator[T, PT]) Value() (value *T, err error) {
 	if item == nil {
 		return nil, nil
 	}
-	v := PT(new(T))
+	var stale bool
 	err = item.Value(func(val []byte) error {
 		if len(val) == 0 {
+			value = new(T)
 			return nil
 		}
-		return v.UnmarshalBinary(val)
+		value, err = it.codec.Decode(val)
+		if err != nil || it.onStale == nil {
+			return err
+		}
+
+		stale, err = it.codec.(codec.Upgradable).IsStale(val)
+		return err
 	})
-	it.cachedValue = (*T)(v)
-	return it.cachedValue, err
+	if err != nil {
+		return value, err
+	}
+
+	if stale {
+		if err := it.onStale(item, bytes.Clone(it.Key()), value); err != nil {
+			return value, err
+		}
+	}
+	it.cachedValue = value
+	return it.cachedValue, nil
 }
@@ -2,9 +2,13 @@ package serialized
 
 import (
 	"encoding"
+	"errors"
+	"fmt"
+	"slices"
 
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
 	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
 )
 
@@ -15,9 +19,15 @@ type BSP[T any] interface {
 	encoding.BinaryUnmarshaler
 }
 
-type Store[T any, PT BSP[T]] struct {
+// Pointer is an interface for pointer of T.
+type Pointer[T any] interface {
+	*T
+}
+
+type Store[T any, PT Pointer[T]] struct {
 	base   badgerutils.Instantiator[badgerutils.BadgerStore]
 	prefix []byte
+	codec  codec.Codec[*T]
 }
 
 // New creates a new Store.
@@ -25,6 +35,25 @@ func New[
 	T any,
 	PT BSP[T],
 ](base badgerutils.Instantiator[badgerutils.BadgerStore]) *Store[T, PT] {
+	return newStore[T, PT](base, BinaryCodec[T, PT]())
+}
+
+// NewWithCodec creates a new Store that serializes values using the given codec.
+func NewWithCodec[T any](
+	base badgerutils.Instantiator[badgerutils.BadgerStore],
+	c codec.Codec[*T],
+) *Store[T, *T] {
+	if c == nil {
+		panic("codec must not be nil")
+	}
+
+	return newStore[T, *T](base, c)
+}
+
+func newStore[T any, PT Pointer[T]](
+	base badgerutils.Instantiator[badgerutils.BadgerStore],
+	c codec.Codec[*T],
+) *Store[T, PT] {
 	var prefix []byte
 	if pfx, ok := base.(prefixed); ok {
 		prefix = pfx.Prefix()
@@ -33,6 +62,7 @@ func New[
 	return &Store[T, PT]{
 		base:   base,
 		prefix: prefix,
+		codec:  c,
 	}
 }
 
@@ -45,23 +75,48 @@ func (s *Store[T, PT]) Prefix() []byte {
 	return s.prefix
 }
 
+// Codec returns the codec used to serialize values.
+func (s *Store[T, PT]) Codec() codec.Codec[*T] {
+	return s.codec
+}
+
+// Entry encodes the value and returns its badger.Entry with the whole key including the prefix of the store,
+// so that it can be written without a transaction, e.g. by badger.WriteBatch.
+func (s *Store[T, PT]) Entry(key []byte, value *T, opts ...badgerutils.EntryOption) (*badger.Entry, error) {
+	var data []byte
+	if value != nil {
+		var err error
+		data, err = s.codec.Encode(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return badgerutils.NewEntryOptions(opts...).NewEntry(slices.Concat(s.prefix, key), data), nil
+}
+
 // Instantiate creates a new Instance.
-func (s *Store[T, PT]) Instantiate(txn *badger.Txn) badgerutils.StoreInstance[[]byte, *T, *T, badgerutils.Iterator[[]byte, *T]] {
+func (s *Store[T, PT]) Instantiate(txn *badger.Txn) *Instance[T, PT] {
 	var base badgerutils.BadgerStore = txn
 	if s.base != nil {
 		base = s.base.Instantiate(txn)
 	}
 
+	u, ok := s.codec.(codec.Upgradable)
 	return &Instance[T, PT]{
-		base:   base,
-		prefix: s.prefix,
+		base:      base,
+		prefix:    s.prefix,
+		codec:     s.codec,
+		writeBack: ok && u.WriteBack(),
 	}
 }
 
 // Instance is a store that serializes all keys and values.
-type Instance[T any, PT BSP[T]] struct {
-	base   badgerutils.BadgerStore
-	prefix []byte
+type Instance[T any, PT Pointer[T]] struct {
+	base      badgerutils.BadgerStore
+	prefix    []byte
+	codec     codec.Codec[*T]
+	writeBack bool
 }
 
 // Prefix returns the prefix of the store.
@@ -84,10 +139,14 @@ func (s *Instance[T, PT]) Get(key []byte) (value *T, err error) {
 func (s *Instance[T, PT]) NewIterator(opts badger.IteratorOptions) badgerutils.Iterator[[]byte, *T] {
 	var iter badgerutils.BadgerIterator = s.base.NewIterator(opts)
 	if pfx := s.Prefix(); pfx != nil {
-		iter = pstore.NewIterator(iter, pfx)
+		iter = pstore.NewIterator(iter, pfx).WithOptions(opts)
 	}
 
-	return NewIterator[T, PT](iter)
+	it := NewIteratorWithCodec(iter, s.codec)
+	if s.writeBack {
+		it.onStale = s.upgrade
+	}
+	return it
 }
 
 // GetWithItem is similar to Get, but it also returns the badger.Item as well.
@@ -96,24 +155,104 @@ func (s *Instance[T, PT]) GetWithItem(key []byte) (item *badger.Item, value *T,
 	if err != nil {
 		return nil, nil, err
 	}
-	v := PT(new(T))
+	var stale bool
 	err = item.Value(func(val []byte) error {
-		return v.UnmarshalBinary(val)
+		value, err = s.decode(val)
+		if err != nil || !s.writeBack || len(val) == 0 {
+			return err
+		}
+
+		stale, err = s.codec.(codec.Upgradable).IsStale(val)
+		return err
 	})
-	return item, (*T)(v), err
+	if err != nil {
+		return item, value, err
+	}
+
+	if stale {
+		err = s.upgrade(item, key, value)
+	}
+	return item, value, err
 }
 
-// Set marshals the value as binary and sets it to the key.
+// upgrade writes back the value that is decoded from a stale format in the latest format
+// keeping the expiry and the user metadata of the item. It's a no-op in read-only transactions.
+// GetItem returns the badger.Item of the key without decoding its value, so it's never written back.
+func (s *Instance[T, PT]) GetItem(key []byte) (*badger.Item, error) {
+	return s.base.Get(key)
+}
+
+// Decode decodes the value of an item returned by GetItem without writing it back.
+func (s *Instance[T, PT]) Decode(item *badger.Item) (value *T, err error) {
+	err = item.Value(func(val []byte) error {
+		value, err = s.decode(val)
+		return err
+	})
+	return value, err
+}
+
+func (s *Instance[T, PT]) decode(val []byte) (*T, error) {
+	// Nil values are set as empty ones, which are decoded as zero values the same as Iterator.
+	if len(val) == 0 {
+		return new(T), nil
+	}
+	return s.codec.Decode(val)
+}
+
+func (s *Instance[T, PT]) upgrade(item *badger.Item, key []byte, value *T) error {
+	err := s.SetWithOptions(key, value,
+		badgerutils.WithExpiresAt(item.ExpiresAt()),
+		badgerutils.WithMeta(item.UserMeta()),
+	)
+	if errors.Is(err, badger.ErrReadOnlyTxn) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write back upgraded value: %w", err)
+	}
+
+	return nil
+}
+
+// Set encodes the value using the codec of the store and sets it to the key.
 func (s *Instance[T, PT]) Set(key []byte, value *T) error {
+	return s.SetWithOptions(key, value)
+}
+
+// SetWithOptions is a variant of Set that writes the entry with the given options, e.g. a TTL.
+func (s *Instance[T, PT]) SetWithOptions(key []byte, value *T, opts ...badgerutils.EntryOption) error {
 	var (
 		data []byte
 		err  error
 	)
 	if value != nil {
-		data, err = PT(value).MarshalBinary()
+		data, err = s.codec.Encode(value)
 		if err != nil {
 			return err
 		}
 	}
-	return s.base.Set(key, data)
+	if len(opts) == 0 {
+		return s.base.Set(key, data)
+	}
+	return s.base.SetEntry(badgerutils.NewEntryOptions(opts...).NewEntry(key, data))
+}
+
+// BinaryCodec returns a codec of *T that uses the encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler implementations of T.
+func BinaryCodec[T any, PT BSP[T]]() codec.Codec[*T] {
+	return binaryCodec[T, PT]{}
+}
+
+type binaryCodec[T any, PT BSP[T]] struct{}
+
+// Encode implements the codec.Codec interface.
+func (binaryCodec[T, PT]) Encode(v *T) ([]byte, error) {
+	return PT(v).MarshalBinary()
+}
+
+// Decode implements the codec.Codec interface.
+func (binaryCodec[T, PT]) Decode(bz []byte) (*T, error) {
+	v := PT(new(T))
+	err := v.UnmarshalBinary(bz)
+	return (*T)(v), err
 }
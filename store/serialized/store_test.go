@@ -1,10 +1,15 @@
 package serialized_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	"github.com/ehsanranjbar/badgerutils/codec"
 	"github.com/ehsanranjbar/badgerutils/store/serialized"
 	"github.com/ehsanranjbar/badgerutils/testutil"
 	"github.com/stretchr/testify/require"
@@ -78,3 +83,137 @@ func TestStore(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+type PlainStruct struct {
+	A int
+	B []string
+}
+
+func TestStore_WithCodec(t *testing.T) {
+	store := serialized.NewWithCodec(nil, codec.Msgpack[*PlainStruct]())
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	values := map[string]*PlainStruct{
+		"a": {A: 1, B: []string{"foo"}},
+		"b": {A: 2, B: []string{"bar", "baz"}},
+	}
+	for k, v := range values {
+		err := ins.Set([]byte(k), v)
+		require.NoError(t, err)
+	}
+
+	actual, err := ins.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, values["a"], actual)
+
+	iter := ins.NewIterator(badger.DefaultIteratorOptions)
+	defer iter.Close()
+	for iter.Rewind(); iter.Valid(); iter.Next() {
+		v, err := iter.Value()
+		require.NoError(t, err)
+		require.Equal(t, values[string(iter.Key())], v)
+	}
+
+	require.Panics(t, func() {
+		serialized.NewWithCodec[PlainStruct](nil, nil)
+	})
+}
+
+func TestStore_NilValue(t *testing.T) {
+	store := serialized.NewWithCodec(nil, codec.JSON[*PlainStruct]())
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+	require.NoError(t, ins.Set([]byte("a"), nil))
+
+	actual, err := ins.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, &PlainStruct{}, actual)
+
+	iter := ins.NewIterator(badger.DefaultIteratorOptions)
+	defer iter.Close()
+	iter.Rewind()
+	require.True(t, iter.Valid())
+	v, err := iter.Value()
+	require.NoError(t, err)
+	require.Equal(t, actual, v)
+}
+
+func TestStore_WriteBack(t *testing.T) {
+	upgrade := func(old []byte) ([]byte, error) {
+		return bytes.Replace(old, []byte(`"X"`), []byte(`"A"`), 1), nil
+	}
+	store := serialized.NewWithCodec(nil, codec.Versioned(codec.JSON[*TestStruct](), 1).
+		WithUpgrader(0, upgrade).
+		WithWriteBack())
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	for _, key := range []string{"a", "b"} {
+		require.NoError(t, txn.Set([]byte(key), []byte(`{"X":1,"B":"foo"}`)))
+	}
+	isCurrent := func(key string) bool {
+		item, err := txn.Get([]byte(key))
+		require.NoError(t, err)
+		bz, err := item.ValueCopy(nil)
+		require.NoError(t, err)
+		version, _, err := codec.SplitVersion(bz)
+		require.NoError(t, err)
+		return version == 1
+	}
+
+	v, err := ins.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, &TestStruct{A: 1, B: "foo"}, v)
+	require.True(t, isCurrent("a"))
+	require.False(t, isCurrent("b"))
+
+	iter := ins.NewIterator(badger.DefaultIteratorOptions)
+	for iter.Rewind(); iter.Valid(); iter.Next() {
+		v, err := iter.Value()
+		require.NoError(t, err)
+		require.Equal(t, &TestStruct{A: 1, B: "foo"}, v)
+	}
+	iter.Close()
+	require.True(t, isCurrent("b"))
+}
+
+func TestStore_SetWithOptions(t *testing.T) {
+	store := serialized.NewWithCodec(nil, codec.Versioned(codec.JSON[*TestStruct](), 1).
+		WithUpgrader(0, func(old []byte) ([]byte, error) { return old, nil }).
+		WithWriteBack())
+
+	txn := testutil.PrepareTxn(t, true)
+	ins := store.Instantiate(txn)
+
+	ttl := badgerutils.WithTTL(time.Hour)
+	require.NoError(t, ins.SetWithOptions([]byte("a"), &TestStruct{A: 1}, ttl, badgerutils.WithMeta(7)))
+
+	item, err := txn.Get([]byte("a"))
+	require.NoError(t, err)
+	expiresAt := badgerutils.NewEntryOptions(ttl).ExpiresAt
+	require.Equal(t, expiresAt, item.ExpiresAt())
+	require.Equal(t, byte(7), item.UserMeta())
+
+	t.Run("WriteBack", func(t *testing.T) {
+		e := badgerutils.NewEntryOptions(ttl, badgerutils.WithMeta(3)).NewEntry([]byte("b"), []byte(`{"A":2}`))
+		require.NoError(t, txn.SetEntry(e))
+
+		v, err := ins.Get([]byte("b"))
+		require.NoError(t, err)
+		require.Equal(t, &TestStruct{A: 2}, v)
+
+		item, err := txn.Get([]byte("b"))
+		require.NoError(t, err)
+		bz, err := item.ValueCopy(nil)
+		require.NoError(t, err)
+		version, _, err := codec.SplitVersion(bz)
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), version)
+		require.Equal(t, expiresAt, item.ExpiresAt())
+		require.Equal(t, byte(3), item.UserMeta())
+	})
+}
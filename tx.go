@@ -0,0 +1,170 @@
+package badgerutils
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const (
+	// DefaultMaxRetries is the default number of times that RunTx retries a conflicting transaction.
+	DefaultMaxRetries = 5
+	// DefaultBackoff is the default delay before the first retry of RunTx which is doubled on each retry.
+	DefaultBackoff = 10 * time.Millisecond
+	// DefaultMaxBackoff is the default maximum delay between the retries of RunTx.
+	DefaultMaxBackoff = time.Second
+)
+
+// RetryPolicy is the policy of RunTx for retrying the transactions that fail with badger.ErrConflict.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WithMaxRetries sets the number of times that a conflicting transaction is retried, zero disables retries.
+func WithMaxRetries(n int) func(*RetryPolicy) {
+	if n < 0 {
+		panic("max retries must not be negative")
+	}
+
+	return func(p *RetryPolicy) {
+		p.MaxRetries = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the maximum delay between the retries.
+// The delay is doubled on each retry and a random jitter of up to half of it is subtracted.
+func WithBackoff(initial, max time.Duration) func(*RetryPolicy) {
+	if initial < 0 || max < initial {
+		panic("invalid backoff")
+	}
+
+	return func(p *RetryPolicy) {
+		p.Backoff = initial
+		p.MaxBackoff = max
+	}
+}
+
+// RunTx runs fn in a read-write transaction and commits it. If the commit fails with badger.ErrConflict,
+// the whole transaction is run again after a backoff according to the retry policy, so fn must not have
+// side effects outside of the transaction.
+// RunTx doesn't support databases opened in managed mode (see badger.OpenManaged) as badger.DB.Update panics on them.
+func RunTx(db *badger.DB, fn func(txn *badger.Txn) error, opts ...func(*RetryPolicy)) error {
+	p := RetryPolicy{
+		MaxRetries: DefaultMaxRetries,
+		Backoff:    DefaultBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	delay := p.Backoff
+	for retries := 0; ; retries++ {
+		err := db.Update(fn)
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+		if retries == p.MaxRetries {
+			return fmt.Errorf("failed to commit transaction after %d retries: %w", retries, err)
+		}
+
+		if delay > 0 {
+			time.Sleep(delay - rand.N(delay/2+1))
+		}
+		delay = min(delay*2, p.MaxBackoff)
+	}
+}
+
+// Batched is a writer for bulk work that spans as many transactions as needed. The work is done in a single
+// transaction until an operation fails with badger.ErrTxnTooBig, then the transaction is committed and
+// the operation is run again in a new transaction. Since the writes of the failed operation before the error
+// are committed as well, operations must be idempotent. The instances of the stores must be obtained through
+// the functions returned by Register so they're instantiated again for each new transaction.
+// Batched doesn't support databases opened in managed mode as badger rejects committing its transactions
+// without a commit timestamp.
+type Batched struct {
+	db  *badger.DB
+	txn *badger.Txn
+	ops int
+}
+
+// NewBatched creates a new Batched writer.
+func NewBatched(db *badger.DB) *Batched {
+	return &Batched{
+		db:  db,
+		txn: db.NewTransaction(true),
+	}
+}
+
+// Register returns a function that returns the instance of s in the current transaction of b.
+func Register[I any](b *Batched, s Instantiator[I]) func() I {
+	var (
+		ins I
+		txn *badger.Txn
+	)
+	return func() I {
+		if txn != b.txn {
+			ins = s.Instantiate(b.txn)
+			txn = b.txn
+		}
+		return ins
+	}
+}
+
+// Txn returns the current transaction.
+func (b *Batched) Txn() *badger.Txn {
+	return b.txn
+}
+
+// Do runs the operation fn in the current transaction. If it fails with badger.ErrTxnTooBig,
+// the transaction is committed and fn is run again in a new one.
+func (b *Batched) Do(fn func() error) error {
+	err := fn()
+	if !errors.Is(err, badger.ErrTxnTooBig) {
+		if err == nil {
+			b.ops++
+		}
+		return err
+	}
+	if b.ops == 0 {
+		return fmt.Errorf("operation doesn't fit in a single transaction: %w", err)
+	}
+
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	return b.Do(fn)
+}
+
+// Flush commits the current transaction and starts a new one.
+func (b *Batched) Flush() error {
+	err := b.txn.Commit()
+	b.txn = b.db.NewTransaction(true)
+	b.ops = 0
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Commit commits the current transaction. The writer must not be used afterwards.
+func (b *Batched) Commit() error {
+	err := b.txn.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Discard discards the current transaction and the writer must not be used afterwards.
+// The transactions that are already committed are not affected. It's safe to call Discard after Commit.
+func (b *Batched) Discard() {
+	b.txn.Discard()
+}
@@ -0,0 +1,133 @@
+package badgerutils_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ehsanranjbar/badgerutils"
+	pstore "github.com/ehsanranjbar/badgerutils/store/prefix"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTx(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	key := []byte("counter")
+	increment := func(conflicts int) (int, error) {
+		var attempts int
+		err := badgerutils.RunTx(db, func(txn *badger.Txn) error {
+			attempts++
+			var n uint64
+			item, err := txn.Get(key)
+			if err == nil {
+				err = item.Value(func(val []byte) error {
+					n = binary.BigEndian.Uint64(val)
+					return nil
+				})
+			}
+			if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+
+			if attempts <= conflicts {
+				// A concurrent write to the key read by the transaction.
+				err := db.Update(func(txn *badger.Txn) error {
+					return txn.Set(key, binary.BigEndian.AppendUint64(nil, n))
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return txn.Set(key, binary.BigEndian.AppendUint64(nil, n+1))
+		}, badgerutils.WithMaxRetries(2), badgerutils.WithBackoff(time.Millisecond, 2*time.Millisecond))
+		return attempts, err
+	}
+
+	attempts, err := increment(0)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts)
+
+	attempts, err = increment(2)
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	attempts, err = increment(3)
+	require.ErrorIs(t, err, badger.ErrConflict)
+	require.Equal(t, 3, attempts)
+
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		require.NoError(t, err)
+		return item.Value(func(val []byte) error {
+			require.Equal(t, uint64(2), binary.BigEndian.Uint64(val))
+			return nil
+		})
+	}))
+}
+
+type countingInstantiator struct {
+	badgerutils.Instantiator[badgerutils.BadgerStore]
+	n int
+}
+
+func (c *countingInstantiator) Instantiate(txn *badger.Txn) badgerutils.BadgerStore {
+	c.n++
+	return c.Instantiator.Instantiate(txn)
+}
+
+func TestBatched(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions("").
+		WithInMemory(true).
+		WithMemTableSize(1 << 20).
+		WithValueThreshold(1 << 10).
+		WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := &countingInstantiator{Instantiator: pstore.New(nil, []byte("p"))}
+	b := badgerutils.NewBatched(db)
+	defer b.Discard()
+	ins := badgerutils.Register(b, store)
+
+	const n = 10000
+	for i := range n {
+		err := b.Do(func() error {
+			return ins().Set(binary.BigEndian.AppendUint64(nil, uint64(i)), []byte("value"))
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, b.Commit())
+	require.Greater(t, store.n, 1)
+
+	var count int
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.IteratorOptions{Prefix: []byte("p")})
+		defer iter.Close()
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			count++
+		}
+		return nil
+	}))
+	require.Equal(t, n, count)
+
+	t.Run("TooBig", func(t *testing.T) {
+		b := badgerutils.NewBatched(db)
+		defer b.Discard()
+
+		err := b.Do(func() error {
+			for i := range n {
+				if err := b.Txn().Set(binary.BigEndian.AppendUint64([]byte("q"), uint64(i)), nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, badger.ErrTxnTooBig)
+	})
+}